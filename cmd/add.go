@@ -2,11 +2,16 @@ package cmd
 
 import (
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -17,142 +22,582 @@ import (
 )
 
 var addCmd = &cobra.Command{
-	Use:   "add [url_or_file_path]",
-	Short: "Scrapes a URL or reads a file, generates embeddings, and stores the content",
+	Use:   "add [url_or_path]",
+	Short: "Scrapes a URL or reads a file or directory, generates embeddings, and stores the content",
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		input := args[0]
 		context, _ := cmd.Flags().GetString("context")
 		verbose, _ := cmd.Flags().GetBool("verbose")
+		stopAt, _ := cmd.Flags().GetString("stop-at")
+		maxPages, _ := cmd.Flags().GetInt("max-pages")
+		splitSections, _ := cmd.Flags().GetBool("sections")
+		adaptive, _ := cmd.Flags().GetBool("adaptive")
+		restrictPath, _ := cmd.Flags().GetBool("restrict-path")
+		includePatterns, _ := cmd.Flags().GetStringArray("include")
+		excludePatterns, _ := cmd.Flags().GetStringArray("exclude")
+		tagValues, _ := cmd.Flags().GetStringArray("tag")
+		tags := parseTags(tagValues)
+		failFast, _ := cmd.Flags().GetBool("fail-fast")
+		showReport, _ := cmd.Flags().GetBool("report")
+		conditional, _ := cmd.Flags().GetBool("conditional")
+		chunkSize, _ := cmd.Flags().GetInt("chunk-size")
+		chunkOverlap, _ := cmd.Flags().GetInt("chunk-overlap")
+		minDepth, _ := cmd.Flags().GetInt("min-depth")
+		maxDepthStore, _ := cmd.Flags().GetInt("max-depth-store")
+		batchSize, _ := cmd.Flags().GetInt("batch-size")
+		glob, _ := cmd.Flags().GetString("glob")
+		saveCrawl, _ := cmd.Flags().GetString("save-crawl")
+		fromCrawl, _ := cmd.Flags().GetString("from-crawl")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		single, _ := cmd.Flags().GetBool("single")
+		stateFile, _ := cmd.Flags().GetString("state-file")
+		maxDepth, _ := cmd.Flags().GetInt("max-depth")
+		requestDelay, _ := cmd.Flags().GetDuration("request-delay")
+		maxConcurrent, _ := cmd.Flags().GetInt("max-concurrent")
+		dedupThreshold, _ := cmd.Flags().GetFloat64("dedup-threshold")
+		if dedupThreshold < 0 || dedupThreshold > 1 {
+			log.Fatalf("--dedup-threshold must be between 0 and 1, got %g", dedupThreshold)
+		}
+		if maxDepth < 0 {
+			log.Fatalf("--max-depth must not be negative, got %d", maxDepth)
+		}
+		if requestDelay < 0 {
+			log.Fatalf("--request-delay must not be negative, got %s", requestDelay)
+		}
+		if maxConcurrent < 1 {
+			log.Fatalf("--max-concurrent must be at least 1, got %d", maxConcurrent)
+		}
 
 		dbPath := viper.GetString("db")
-		workerURL := viper.GetString("worker-url")
+		embeddingTemplate := viper.GetString("embedding-template")
 
-		fmt.Println(input, dbPath, workerURL, context)
+		// A dry run only crawls and converts, so it needs neither storage nor embeddings
+		var st *storage.Storage
+		var emb llm.Embedder
+		var planned []plannedPage
+		if !dryRun {
+			// Initialize storage
+			var err error
+			st, err = storage.NewStorage(dbPath)
+			if err != nil {
+				log.Fatalf("Failed to initialize storage: %v", err)
+			}
+			defer st.Close()
 
-		// Initialize storage
-	
-st, err := storage.NewStorage(dbPath)
-		if err != nil {
-			log.Fatalf("Failed to initialize storage: %v", err)
+			// Initialize LLM
+			emb, err = newEmbedder()
+			if err != nil {
+				log.Fatalf("Failed to initialize embeddings: %v", err)
+			}
 		}
-		defer st.Close()
 
-		// Initialize LLM
-		if workerURL == "" {
-			log.Fatalf("worker-url is required for add command")
+		// Per-page problems are warnings unless --fail-fast is set; the command
+		// only fails outright when nothing could be indexed
+		indexed, warnings := 0, 0
+		warn := func(format string, args ...interface{}) {
+			if failFast {
+				log.Fatalf(format, args...)
+			}
+			warnings++
+			log.Printf("Warning: "+format, args...)
 		}
-		emb := llm.NewEmbeddings(workerURL)
 
 		// Initialize API
 		ponsAPI := api.NewAPI(st, emb)
 
-		var contentToStore string
-		var docURL string
-		var docTitle string
-		var docDescription string
-		var sourceType string
+		// Near-duplicate documents are dropped after embedding, before they are stored
+		var dedup *api.Deduplicator
+		duplicates := 0
+		if dedupThreshold > 0 {
+			dedup = api.NewDeduplicator(dedupThreshold)
+		}
+
+		// addFile stores a local file as documents titled title and returns how many
+		// documents were indexed, counting chunks that were already up to date
+		addFile := func(filePath, title string) (int, error) {
+			fileContent, err := os.ReadFile(filePath)
+			if err != nil {
+				return 0, fmt.Errorf("failed to read file %s: %v", filePath, err)
+			}
+			sourceType := "file_read"
+			content := string(fileContent)
+			if scraper.IsPDF(filePath, fileContent) {
+				sourceType = "pdf"
+				content, err = scraper.ExtractPDFText(fileContent)
+				if err != nil {
+					return 0, fmt.Errorf("failed to extract text from PDF %s: %v", filePath, err)
+				}
+			}
+			docURL := "file://" + filePath // Use a file URL scheme
+
+			language := scraper.DetectLanguage(content)
+
+			// Long files are embedded as overlapping chunks stored as url#chunkN
+			chunks := scraper.ChunkMarkdown(content, chunkSize, chunkOverlap)
+			if dryRun {
+				planned = append(planned, plannedPage{path: filePath, title: title, length: len(content), chunks: len(chunks)})
+				return len(chunks), nil
+			}
+			chunkURLs := scraper.ChunkURLs(docURL, len(chunks))
+			if err := ponsAPI.DeleteStaleChunks(docURL, context, chunkURLs); err != nil {
+				warn("Failed to remove outdated chunks of %s: %v", docURL, err)
+			}
+
+			// Skip the slow embedding call for chunks that haven't changed
+			fileIndexed := 0
+			var changed []*storage.Document
+			var inputs []string
+			for i, chunk := range chunks {
+				checksum := fmt.Sprintf("%x", sha256.Sum256([]byte(chunk)))
+				if unchanged(st, chunkURLs[i], context, checksum) {
+					if verbose {
+						fmt.Printf("  - Unchanged: %s\n", chunkURLs[i])
+					}
+					fileIndexed++
+					continue
+				}
+				embeddingInput, err := llm.RenderEmbeddingInput(embeddingTemplate, llm.EmbeddingInput{Title: title, Context: context, Content: chunk})
+				if err != nil {
+					log.Fatalf("Failed to prepare embedding input: %v", err)
+				}
+				inputs = append(inputs, embeddingInput)
+				changed = append(changed, &storage.Document{
+					URL:        chunkURLs[i],
+					Title:      title,
+					Content:    chunk,
+					Checksum:   checksum,
+					Context:    context,
+					SourceType: sourceType,
+					Language:   language,
+					Tags:       tags,
+				})
+			}
+			if len(changed) == 0 {
+				return fileIndexed, nil
+			}
+
+			// Generate embeddings
+			if verbose {
+				fmt.Printf("  - Generating embeddings for %d chunk(s) of file %s\n", len(changed), filePath)
+			}
+			embeddings, err := llm.GenerateEmbeddingsBatch(emb, inputs)
+			if err != nil {
+				return fileIndexed, fmt.Errorf("failed to generate embeddings for file %s: %v", filePath, err)
+			}
+			for i, doc := range changed {
+				doc.Embeddings = embeddings[i]
+			}
+			if dedup != nil {
+				var dropped int
+				changed, dropped = dedup.Filter(changed)
+				duplicates += dropped
+				if len(changed) == 0 {
+					return fileIndexed, nil
+				}
+			}
+
+			// Store documents
+			if verbose {
+				fmt.Printf("  - Storing documents for file %s\n", filePath)
+			}
+			if err := ponsAPI.UpsertDocuments(changed); err != nil {
+				return fileIndexed, fmt.Errorf("failed to store documents for file %s: %v", filePath, err)
+			}
+			if verbose {
+				fmt.Printf("  - Successfully added file %s\n", filePath)
+			}
+			return fileIndexed + len(changed), nil
+		}
+
+		if info, err := os.Stat(input); err == nil && info.IsDir() {
+			// It's a directory: add every matching file below it
+			if glob != "" {
+				if _, err := filepath.Match(glob, ""); err != nil {
+					log.Fatalf("Invalid --glob pattern: %v", err)
+				}
+			}
+			excludes := make([]*regexp.Regexp, 0, len(excludePatterns))
+			for _, pattern := range excludePatterns {
+				re, err := regexp.Compile(pattern)
+				if err != nil {
+					log.Fatalf("Invalid --exclude pattern %q: %v", pattern, err)
+				}
+				excludes = append(excludes, re)
+			}
 
-		if strings.HasPrefix(input, "http://") || strings.HasPrefix(input, "https://") {
+			files, err := walkFiles(input, glob, excludes, warn)
+			if err != nil {
+				log.Fatalf("Failed to read directory %s: %v", input, err)
+			}
+			if verbose {
+				fmt.Printf("Found %d matching files in %s\n", len(files), input)
+			}
+			for _, rel := range files {
+				if verbose {
+					fmt.Printf("  - Processing %s\n", rel)
+				}
+				fileIndexed, err := addFile(filepath.Join(input, rel), filepath.ToSlash(rel))
+				indexed += fileIndexed
+				if err != nil {
+					warn("Skipping %s: %v", rel, err)
+				}
+			}
+		} else if strings.HasPrefix(input, "http://") || strings.HasPrefix(input, "https://") {
 			// It's a URL, proceed with scraping
-			url := input
-			sourceType = "web_scrape"
+			rootURL := input
+			sourceType := "web_scrape"
 			config := scraper.DefaultConfig()
 			config.Verbose = verbose // Set verbosity for scraper
-			s := scraper.New(url, config)
-			if err := s.GetContent(); err != nil {
-				log.Fatalf("Failed to get content for metadata: %v", err)
+			config.UserAgent = viper.GetString("user-agent")
+			config.ProxyURL = viper.GetString("proxy")
+			config.MaxPages = maxPages
+			config.MaxDepth = maxDepth
+			config.RequestDelay = requestDelay
+			config.MaxConcurrent = maxConcurrent
+			if !single {
+				config.StateFile = stateFile
 			}
-			if err := s.GetMetadata(); err != nil {
-				log.Fatalf("Failed to get metadata: %v", err)
+			config.Adaptive = adaptive
+			config.ChunkSize = chunkSize
+			config.ChunkOverlap = chunkOverlap
+			config.RestrictToPathPrefix = restrictPath
+			if stopAt != "" {
+				stopPattern, err := regexp.Compile(stopAt)
+				if err != nil {
+					log.Fatalf("Invalid --stop-at pattern: %v", err)
+				}
+				config.StopWhen = func(path, content string) bool {
+					return stopPattern.MatchString(content)
+				}
+			}
+			config.IncludePatterns = includePatterns
+			config.ExcludePatterns = excludePatterns
+			applyAccessFlags(cmd, config)
+			if conditional && !dryRun {
+				config.PriorValidators = func(pageURL string) scraper.Validators {
+					etag, lastModified, err := st.GetValidators(pageURL)
+					if err != nil {
+						log.Printf("Warning: %v", err)
+					}
+					return scraper.Validators{ETag: etag, LastModified: lastModified}
+				}
+			}
+			report := newIngestReport()
+			config.OnPage = func(_ string, _ int, err error) {
+				report.crawled++
+				if err != nil {
+					report.skip(skipFetchFailed)
+					warn("%v", err)
+				}
+			}
+			s, err := scraper.New(rootURL, config)
+			if err != nil {
+				log.Fatalf("Failed to initialize scraper: %v", err)
+			}
+			if fromCrawl != "" {
+				// Store the pages of an earlier crawl instead of crawling again
+				result, err := scraper.LoadCrawlResult(fromCrawl)
+				if err != nil {
+					log.Fatalf("Failed to load crawl: %v", err)
+				}
+				if result.URL != rootURL {
+					log.Fatalf("%s holds a crawl of %s, not %s", fromCrawl, result.URL, rootURL)
+				}
+				s.Restore(result)
+			} else if _, err := os.Stat(config.StateFile); config.StateFile != "" && err == nil {
+				// Continue the interrupted crawl saved in the state file
+				fmt.Printf("Resuming the crawl saved in %s\n", config.StateFile)
+				if err := s.ResumeFrom(config.StateFile); err != nil {
+					if len(s.SubPaths) == 0 {
+						log.Fatalf("Failed to resume crawl: %v", err)
+					}
+					warn("Failed to finish resumed crawl, indexing the pages crawled so far: %v", err)
+				}
+			} else {
+				if err := s.GetContent(); err != nil {
+					warn("Failed to get content for metadata: %v", err)
+				} else if err := s.GetMetadata(); err != nil {
+					warn("Failed to get metadata: %v", err)
+				}
+				if single {
+					if _, _, err := s.ScrapeSinglePage(); err != nil {
+						log.Fatalf("Failed to scrape %s: %v", rootURL, err)
+					}
+				} else if err := s.GetAllPaths(); err != nil {
+					warn("Failed to get all paths, indexing the pages crawled so far: %v", err)
+				}
+				if saveCrawl != "" {
+					if err := s.Result().Save(saveCrawl); err != nil {
+						warn("Failed to save crawl: %v", err)
+					} else if verbose {
+						fmt.Printf("Saved crawl to %s\n", saveCrawl)
+					}
+				}
 			}
-			if err := s.GetAllPaths(); err != nil {
-				log.Fatalf("Failed to get all paths: %v", err)
+
+			// Pages a conditional request found unchanged keep their stored documents
+			for range s.SubPathsNotModified {
+				report.skip(skipNotModified)
+				indexed++
 			}
 
 			// Process and store each page
 			if verbose {
 				fmt.Println("Processing and storing documents...")
 			}
-			parser := &scraper.Parser{}
-			for subpath, content := range s.SubPathsHTMLContent {
-				if verbose {
-					fmt.Printf("  - Processing %s\n", subpath)
+			parser := &scraper.Parser{StripSelectors: config.StripSelectors}
+			storedURLs := make(map[string]bool)
+
+			// Documents are stored in batches, each in one transaction; pages count as
+			// stored once the batch holding their documents is committed
+			var pending []*storage.Document
+			pendingPages := 0
+			flush := func() {
+				if len(pending) == 0 {
+					return
+				}
+				if err := ponsAPI.UpsertDocuments(pending); err != nil {
+					for i := 0; i < pendingPages; i++ {
+						report.skip(skipStoreFailed)
+					}
+					warn("Failed to store %d documents: %v", len(pending), err)
+				} else {
+					report.stored += pendingPages
+					report.documents += len(pending)
+					indexed += len(pending)
+					if verbose {
+						fmt.Printf("  - Stored %d documents\n", len(pending))
+					}
 				}
+				pending, pendingPages = nil, 0
+			}
+			for _, subpath := range sortedKeys(s.SubPathsHTMLContent) {
+				content := s.SubPathsHTMLContent[subpath]
 
-				// Convert HTML to Markdown
-				markdownContent, err := parser.ToMarkdown(content)
-				if err != nil {
-					log.Printf("Failed to convert HTML to markdown for %s: %v", subpath, err)
+				// Pages outside the stored depth range are still crawled for links, just not indexed
+				depth := s.SubPathsDepth[subpath]
+				if depth < minDepth || (maxDepthStore >= 0 && depth > maxDepthStore) {
+					report.skip(skipOutsideDepth)
+					if verbose {
+						fmt.Printf("  - Skipping %s (depth %d is outside the stored range)\n", subpath, depth)
+					}
 					continue
 				}
 
-				// Generate embeddings
-				if verbose {
-					fmt.Printf("    - Generating embeddings for %s\n", subpath)
+				// Prefer the page's declared canonical URL so aliases of a page are stored once
+				docURL := s.PageURL(subpath)
+				if canonical := s.SubPathsCanonical[subpath]; canonical != "" {
+					docURL = canonical
 				}
-				embeddings, err := emb.GenerateEmbeddings(markdownContent)
-				if err != nil {
-					log.Printf("Failed to generate embeddings for %s: %v", subpath, err)
+				if storedURLs[docURL] {
+					report.skip(skipDuplicate)
+					if verbose {
+						fmt.Printf("  - Skipping %s (same canonical URL as an earlier page: %s)\n", subpath, docURL)
+					}
 					continue
 				}
+				storedURLs[docURL] = true
+				metadata := s.PageMetadata(subpath)
 
-				// Calculate checksum
-				checksum := fmt.Sprintf("%x", sha256.Sum256([]byte(markdownContent)))
+				if verbose {
+					fmt.Printf("  - Processing %s\n", subpath)
+				}
 
-				// Store document
-			if verbose {
-				fmt.Printf("    - Storing document in bbolt: %s\n", subpath)
-			}
+				// Convert HTML to Markdown, optionally split into anchored sections
+				var sections []scraper.Section
+				if splitSections {
+					sections, err = parser.ToSections(content)
+				} else {
+					var markdownContent string
+					markdownContent, err = parser.ToMarkdown(content)
+					sections = []scraper.Section{{Markdown: markdownContent}}
+				}
+				if err != nil {
+					report.skip(skipConversionFailed)
+					warn("Failed to convert HTML to markdown for %s: %v", subpath, err)
+					continue
+				}
+				report.converted++
 
-				if err := ponsAPI.UpsertDocument(url, subpath, s.Metadata.Title, s.Metadata.Description, markdownContent, checksum, context, sourceType, embeddings); err != nil {
-					log.Printf("Failed to store document for %s: %v", subpath, err)
+				if dryRun {
+					page := plannedPage{path: subpath, title: metadata.Title}
+					for _, section := range sections {
+						if strings.TrimSpace(section.Markdown) != "" {
+							page.length += len(section.Markdown)
+							page.chunks += len(scraper.ChunkMarkdown(section.Markdown, config.ChunkSize, config.ChunkOverlap))
+						}
+					}
+					if page.chunks > 0 {
+						planned = append(planned, page)
+					}
 					continue
 				}
 
-				if verbose {
-					fmt.Printf("    - Successfully added %s\n", subpath)
+				// Unchanged chunks aren't rewritten, so record where a page stored under its
+				// canonical URL was fetched from on the documents it already has
+				fetchURL := s.PageURL(subpath)
+				if fetchURL != docURL {
+					if err := st.SetFetchURL(docURL, context, fetchURL); err != nil {
+						warn("%v", err)
+					}
 				}
-			}
-		} else {
-			// It's a file path, read content directly
-			filePath := input
-			sourceType = "file_read"
-			fileContent, err := os.ReadFile(filePath)
-			if err != nil {
-				log.Fatalf("Failed to read file %s: %v", filePath, err)
-			}
-			contentToStore = string(fileContent)
-			docURL = "file://" + filePath // Use a file URL scheme
-			docTitle = filepath.Base(filePath) // Use filename as title
-			docDescription = ""
 
-			// Generate embeddings
-			if verbose {
-				fmt.Printf("  - Generating embeddings for file %s\n", filePath)
-			}
-			embeddings, err := emb.GenerateEmbeddings(contentToStore)
-			if err != nil {
-				log.Fatalf("Failed to generate embeddings for file %s: %v", filePath, err)
-			}
+				pageSourceType := sourceType
+				if s.SubPathsPDF[subpath] {
+					pageSourceType = "pdf"
+				}
 
-			// Calculate checksum
-			checksum := fmt.Sprintf("%x", sha256.Sum256([]byte(contentToStore)))
+				pageEmbedded, pageQueued, pageThin, pageChanged := false, false, true, false
+				for _, section := range sections {
+					if strings.TrimSpace(section.Markdown) == "" {
+						continue
+					}
+					pageThin = false
 
-			// Store document
-			if verbose {
-				fmt.Printf("  - Storing document for file %s\n", filePath)
+					sectionURL := docURL
+					if section.Anchor != "" {
+						sectionURL = docURL + "#" + section.Anchor
+					}
+
+					// Long sections are embedded as overlapping chunks stored as url#chunkN
+					chunks := scraper.ChunkMarkdown(section.Markdown, config.ChunkSize, config.ChunkOverlap)
+					chunkURLs := scraper.ChunkURLs(sectionURL, len(chunks))
+					if err := ponsAPI.DeleteStaleChunks(sectionURL, context, chunkURLs); err != nil {
+						warn("Failed to remove outdated chunks of %s: %v", sectionURL, err)
+					}
+
+					// Collect the chunks that changed since the last run and embed them in one request
+					var changed []*storage.Document
+					var inputs []string
+					for i, chunk := range chunks {
+						chunkURL := chunkURLs[i]
+
+						// Skip embedding and storing content that hasn't changed since the last run.
+						// The title is part of the embedding input, so a new title re-embeds the chunk too.
+						checksum := fmt.Sprintf("%x", sha256.Sum256([]byte(chunk)))
+						if unchangedPage(st, chunkURL, context, checksum, metadata) {
+							if verbose {
+								fmt.Printf("    - Unchanged: %s\n", chunkURL)
+							}
+							indexed++
+							continue
+						}
+						pageChanged = true
+
+						embeddingInput, err := llm.RenderEmbeddingInput(embeddingTemplate, llm.EmbeddingInput{Title: metadata.Title, Context: context, Content: chunk})
+						if err != nil {
+							log.Fatalf("Failed to prepare embedding input: %v", err)
+						}
+						inputs = append(inputs, embeddingInput)
+						changed = append(changed, &storage.Document{
+							URL:          chunkURL,
+							Title:        metadata.Title,
+							Description:  metadata.Description,
+							Content:      chunk,
+							Checksum:     checksum,
+							Context:      context,
+							SourceType:   pageSourceType,
+							Anchor:       section.Anchor,
+							Language:     s.SubPathsLanguage[subpath],
+							ETag:         s.SubPathsValidators[subpath].ETag,
+							LastModified: s.SubPathsValidators[subpath].LastModified,
+							Depth:        depth,
+							SeedURL:      rootURL,
+							FetchURL:     fetchURL,
+							Tags:         tags,
+						})
+					}
+					if len(changed) == 0 {
+						continue
+					}
+
+					// Generate embeddings
+					if verbose {
+						fmt.Printf("    - Generating embeddings for %d chunk(s) of %s\n", len(changed), sectionURL)
+					}
+					embeddings, err := llm.GenerateEmbeddingsBatch(emb, inputs)
+					if err != nil {
+						warn("Failed to generate embeddings for %s: %v", sectionURL, err)
+						continue
+					}
+					pageEmbedded = true
+					for i, doc := range changed {
+						doc.Embeddings = embeddings[i]
+					}
+					if dedup != nil {
+						var dropped int
+						changed, dropped = dedup.Filter(changed)
+						duplicates += dropped
+						if len(changed) == 0 {
+							continue
+						}
+					}
+					pageQueued = true
+
+					// Queue the documents for the next batch
+					for _, doc := range changed {
+						if verbose {
+							fmt.Printf("    - Queueing document: %s\n", doc.URL)
+						}
+					}
+					pending = append(pending, changed...)
+				}
+
+				switch {
+				case pageThin:
+					report.skip(skipThinContent)
+				case !pageChanged:
+					report.skip(skipUnchanged)
+				case !pageEmbedded:
+					report.skip(skipEmbeddingFailed)
+				case !pageQueued:
+					report.skip(skipNearDuplicate)
+				}
+				if pageEmbedded {
+					report.embedded++
+				}
+				if pageQueued {
+					pendingPages++
+				}
+				if len(pending) >= batchSize {
+					flush()
+				}
 			}
+			flush()
 
-			if err := ponsAPI.UpsertDocument(docURL, "", docTitle, docDescription, contentToStore, checksum, context, sourceType, embeddings); err != nil {
-				log.Fatalf("Failed to store document for file %s: %v", filePath, err)
+			if showReport && !dryRun {
+				report.print()
 			}
 
-			if verbose {
-				fmt.Printf("  - Successfully added file %s\n", filePath)
+			// The pages are stored, so there is nothing left to resume. A dry run keeps
+			// the state so the real run can store the crawled pages without fetching them.
+			if config.StateFile != "" && !dryRun {
+				if err := os.Remove(config.StateFile); err != nil && !os.IsNotExist(err) {
+					warn("Failed to remove crawl state %s: %v", config.StateFile, err)
+				}
 			}
+		} else {
+			// It's a file path, read content directly
+			fileIndexed, err := addFile(input, filepath.Base(input)) // Use filename as title
+			if err != nil {
+				log.Fatalf("Failed to add file: %v", err)
+			}
+			indexed += fileIndexed
+		}
+		if dryRun {
+			printPlan(planned)
+			return
+		}
+		if verbose && dedup != nil {
+			fmt.Printf("Removed %d near-duplicate documents.\n", duplicates)
 		}
-		if !verbose {
+		if indexed == 0 {
+			log.Fatalf("Nothing could be indexed from %s", input)
+		}
+		if warnings > 0 {
+			fmt.Printf("\033[33m! Indexed %d documents with %d warnings.\033[0m\n", indexed, warnings)
+		} else if !verbose {
 			fmt.Println("\033[32m\u2713 Documentation added successfully.\033[0m")
 		}
 	},
@@ -162,5 +607,169 @@ func init() {
 	rootCmd.AddCommand(addCmd)
 	addCmd.Flags().BoolP("verbose", "v", false, "Enable verbose output")
 	addCmd.Flags().StringP("context", "c", "", "Context for the scraped documents") // Removed default value
+	addCmd.Flags().Int("chunk-size", 2000, "Split pages longer than this many characters into overlapping chunks (0 stores whole pages)")
+	addCmd.Flags().Int("chunk-overlap", 200, "Characters shared between consecutive chunks")
+	addCmd.Flags().Int("min-depth", 0, "Only store pages found at this crawl depth or deeper (0 is the starting URL)")
+	addCmd.Flags().Int("max-depth-store", -1, "Only store pages found at this crawl depth or shallower (-1 for no limit); the crawl itself still follows links deeper")
+	addCmd.Flags().Bool("sections", false, "Store each anchored heading section of a page as its own document for deep linking")
+	addCmd.Flags().Int("batch-size", 50, "Number of documents stored per database transaction")
+	addCmd.Flags().Bool("report", true, "Print a summary of crawled, converted, embedded and stored pages")
+	addCmd.Flags().Bool("conditional", false, "Send stored ETag/Last-Modified values and skip pages the server reports as unchanged")
+	addCmd.Flags().Bool("fail-fast", false, "Abort on the first page that fails instead of warning and carrying on")
+	addCmd.Flags().Bool("adaptive", false, "Tune crawl concurrency and delay automatically based on how the site responds")
+	addCmd.Flags().String("state-file", "", "Save crawl progress to this file and, if it exists, resume the crawl it holds; removed once the pages are stored")
+	addCmd.Flags().Bool("single", false, "Only add the given URL, without following any of its links")
+	addCmd.Flags().Int("max-depth", scraper.DefaultConfig().MaxDepth, "How many links deep to crawl from the starting URL (0 only fetches the starting page)")
+	addCmd.Flags().Duration("request-delay", scraper.DefaultConfig().RequestDelay, "Minimum time between requests to the same host")
+	addCmd.Flags().Int("max-concurrent", scraper.DefaultConfig().MaxConcurrent, "Maximum number of pages fetched at the same time")
+	addCmd.Flags().Int("max-pages", 0, "Maximum number of pages to crawl (0 means unlimited)")
+	addCmd.Flags().String("stop-at", "", "Stop crawling once a page's content matches this regular expression")
+	addCmd.Flags().Bool("restrict-path", false, "Only crawl pages under the starting URL's path")
+	addCmd.Flags().StringArray("include", nil, "Only crawl paths matching this regular expression (repeatable)")
+	addCmd.Flags().StringArray("tag", nil, "Label to attach to every stored document (repeatable)")
+	addCmd.Flags().StringArray("exclude", nil, "Never crawl paths, or add files of a directory whose relative path matches, this regular expression (repeatable, wins over --include)")
+	addCmd.Flags().String("save-crawl", "", "Save the crawled pages to this file, so they can be stored again with --from-crawl without re-crawling")
+	addCmd.Flags().String("from-crawl", "", "Store the pages of a crawl saved with --save-crawl instead of crawling the URL again")
+	addCmd.Flags().Float64("dedup-threshold", 0, "Drop documents whose embedding has a cosine similarity above this value (0-1) to one already added in this run (0 disables)")
+	addCmd.Flags().Bool("dry-run", false, "Crawl and convert, then list the pages that would be stored without generating embeddings or storing anything")
+	addCmd.Flags().String("glob", "", "When adding a directory, only add files whose name or relative path matches this glob (e.g. \"*.md\")")
+	addAccessFlags(addCmd)
 	addCmd.MarkFlagRequired("context") // Mark as required
 }
+
+// addAccessFlags registers the flags that let the scraper reach gated or JavaScript-rendered
+// pages: extra headers, basic auth, cookies and a render service.
+func addAccessFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArray("header", nil, "Extra HTTP header to send while scraping, as \"Name: value\" (repeatable)")
+	cmd.Flags().String("basic-auth", "", "HTTP basic auth credentials for scraping, as \"user:password\"")
+	cmd.Flags().String("cookie", "", "Cookies to send to the starting URL's host while scraping, as \"name=value; name2=value2\"")
+	cmd.Flags().String("cookie-file", "", "Netscape-format cookie file (as exported from a browser) whose cookies are sent to their matching hosts while scraping")
+	cmd.Flags().String("render-url", "", "Fetch pages through a render service for JavaScript-heavy sites; {url} is replaced with the page URL (e.g. \"http://localhost:8050/render.html?url={url}\")")
+}
+
+// applyAccessFlags sets the headers, credentials, cookies and renderer of config from the
+// flags registered by addAccessFlags, exiting on malformed values.
+func applyAccessFlags(cmd *cobra.Command, config *scraper.Config) {
+	headers, _ := cmd.Flags().GetStringArray("header")
+	basicAuth, _ := cmd.Flags().GetString("basic-auth")
+	cookie, _ := cmd.Flags().GetString("cookie")
+	cookieFile, _ := cmd.Flags().GetString("cookie-file")
+	renderURL, _ := cmd.Flags().GetString("render-url")
+
+	config.Headers = make(map[string]string, len(headers))
+	for _, header := range headers {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			log.Fatalf("Invalid --header %q, expected \"Name: value\"", header)
+		}
+		config.Headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	if basicAuth != "" {
+		config.BasicAuthUser, config.BasicAuthPass, _ = strings.Cut(basicAuth, ":")
+	}
+	if cookie != "" {
+		cookies, err := http.ParseCookie(cookie)
+		if err != nil {
+			log.Fatalf("Invalid --cookie %q, expected \"name=value; name2=value2\": %v", cookie, err)
+		}
+		config.Cookies = cookies
+	}
+	config.CookieFile = cookieFile
+	if renderURL != "" {
+		config.RenderFunc = scraper.RenderEndpoint(renderURL, &http.Client{Timeout: 60 * time.Second})
+	}
+}
+
+// unchanged reports whether the document stored under url in context already has checksum.
+// Lookup failures count as changed so the document is re-embedded rather than skipped.
+func unchanged(st *storage.Storage, url, context, checksum string) bool {
+	stored, err := st.GetChecksum(url, context)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return false
+	}
+	return stored == checksum
+}
+
+// unchangedPage reports whether the document stored for url in context has the given
+// checksum and the page's title and description.
+func unchangedPage(st *storage.Storage, url, context, checksum string, metadata scraper.Metadata) bool {
+	stored, err := st.GetDocument(url, context)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("Warning: %v", err)
+		}
+		return false
+	}
+	return stored.Checksum == checksum && stored.Title == metadata.Title && stored.Description == metadata.Description
+}
+
+// parseTags trims tag values and drops empty and repeated ones.
+func parseTags(values []string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		tag := strings.TrimSpace(value)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// sortedKeys returns the keys of a map in sorted order, for deterministic processing.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// walkFiles returns the paths, relative to dir, of the regular files below dir whose name
+// or relative path matches glob (any file when glob is empty) and whose relative path
+// matches none of excludes. Unreadable entries are reported through warn and skipped.
+func walkFiles(dir, glob string, excludes []*regexp.Regexp, warn func(format string, args ...interface{})) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			warn("Skipping %s: %v", path, err)
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return nil
+		}
+		slashed := filepath.ToSlash(rel)
+		for _, re := range excludes {
+			if re.MatchString(slashed) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if glob != "" {
+			nameMatch, _ := filepath.Match(glob, d.Name())
+			pathMatch, _ := filepath.Match(glob, slashed)
+			if !nameMatch && !pathMatch {
+				return nil
+			}
+		}
+		files = append(files, rel)
+		return nil
+	})
+	return files, err
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tesh254/pons/internal/storage"
+)
+
+// testWorker serves embeddings like the pons worker, for single texts and batches, and
+// records the texts it embeds.
+type testWorker struct {
+	*httptest.Server
+	mu    sync.Mutex
+	texts []string
+}
+
+func newTestWorker(t *testing.T) *testWorker {
+	t.Helper()
+	worker := &testWorker{}
+	worker.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Text json.RawMessage `json:"text"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var texts []string
+		if json.Unmarshal(payload.Text, &texts) != nil {
+			var text string
+			json.Unmarshal(payload.Text, &text)
+			texts = []string{text}
+		}
+		worker.mu.Lock()
+		worker.texts = append(worker.texts, texts...)
+		worker.mu.Unlock()
+
+		data := make([][]float32, len(texts))
+		for i := range data {
+			data[i] = []float32{1, 0, 0}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	t.Cleanup(worker.Close)
+	return worker
+}
+
+// setFlags sets flags of cmd for the duration of a test.
+func setFlags(t *testing.T, cmd *cobra.Command, values map[string]string) {
+	t.Helper()
+	for name, value := range values {
+		flag := cmd.Flags().Lookup(name)
+		old, changed := flag.Value.String(), flag.Changed
+		if err := cmd.Flags().Set(name, value); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			cmd.Flags().Set(name, old)
+			flag.Changed = changed
+		})
+	}
+}
+
+// setConfig sets viper keys for the duration of a test.
+func setConfig(t *testing.T, values map[string]string) {
+	t.Helper()
+	for key, value := range values {
+		old := viper.Get(key)
+		viper.Set(key, value)
+		t.Cleanup(func() { viper.Set(key, old) })
+	}
+}
+
+func TestAddDoesNotStoreBoilerplate(t *testing.T) {
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><title>Guide</title></head><body>
+<nav><a href="/">Home</a> Site navigation</nav>
+<main><h1>Guide</h1><p>Install pons with go install.</p>
+<div class="cookie-banner">We use cookies to improve your experience</div></main>
+<footer>Copyright Example Inc</footer>
+</body></html>`)
+	}))
+	defer site.Close()
+
+	dbPath := filepath.Join(t.TempDir(), "pons.db")
+	setConfig(t, map[string]string{"db": dbPath, "worker-url": newTestWorker(t).URL})
+	setFlags(t, addCmd, map[string]string{"context": "guide", "max-depth": "0", "request-delay": "0s", "report": "false"})
+	addCmd.Run(addCmd, []string{site.URL + "/"})
+
+	st, err := storage.NewStorage(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	docs, err := st.ListAllDocuments("guide")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) == 0 {
+		t.Fatal("no documents stored")
+	}
+	var content strings.Builder
+	for _, doc := range docs {
+		content.WriteString(doc.Content)
+	}
+	if !strings.Contains(content.String(), "Install pons with go install.") {
+		t.Errorf("main content not stored: %q", content.String())
+	}
+	for _, boilerplate := range []string{"Site navigation", "We use cookies", "Copyright Example Inc"} {
+		if strings.Contains(content.String(), boilerplate) {
+			t.Errorf("stored %q: %q", boilerplate, content.String())
+		}
+	}
+}
+
+func TestAddTitlesEachPage(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><title>Home</title><meta name="description" content="The home page"></head>
+<body><main><p>Welcome home.</p><a href="/install">Install</a><a href="/untitled">More</a></main></body></html>`)
+	})
+	mux.HandleFunc("/install", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><title>Installation</title><meta name="description" content="How to install"></head>
+<body><main><p>Run the installer.</p></main></body></html>`)
+	})
+	mux.HandleFunc("/untitled", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><main><p>A page without a title.</p></main></body></html>`)
+	})
+	site := httptest.NewServer(mux)
+	defer site.Close()
+
+	dbPath := filepath.Join(t.TempDir(), "pons.db")
+	worker := newTestWorker(t)
+	setConfig(t, map[string]string{"db": dbPath, "worker-url": worker.URL, "embedding-template": "default"})
+	setFlags(t, addCmd, map[string]string{"context": "site", "max-depth": "1", "request-delay": "0s", "report": "false"})
+	addCmd.Run(addCmd, []string{site.URL + "/"})
+
+	st, err := storage.NewStorage(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	// Pages without a title of their own keep the starting page's
+	for path, want := range map[string][2]string{
+		"/":         {"Home", "The home page"},
+		"/install":  {"Installation", "How to install"},
+		"/untitled": {"Home", "The home page"},
+	} {
+		doc, err := st.GetDocument(site.URL+path, "site")
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if doc.Title != want[0] || doc.Description != want[1] {
+			t.Errorf("%s stored with title %q and description %q, want %q and %q", path, doc.Title, doc.Description, want[0], want[1])
+		}
+	}
+
+	embedded := false
+	for _, text := range worker.texts {
+		if strings.Contains(text, "Run the installer.") {
+			embedded = true
+			if !strings.HasPrefix(text, "Title: Installation\n") {
+				t.Errorf("install page embedded as %q, want its own title", text)
+			}
+		}
+	}
+	if !embedded {
+		t.Error("install page was not embedded")
+	}
+}
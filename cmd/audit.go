@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tesh254/pons/internal/api"
+	"github.com/tesh254/pons/internal/storage"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Reports documents that are missing titles, descriptions, or contexts",
+	Run: func(cmd *cobra.Command, args []string) {
+		samples, _ := cmd.Flags().GetInt("samples")
+
+		dbPath := viper.GetString("db")
+
+		st, err := storage.NewStorage(dbPath)
+		if err != nil {
+			log.Fatalf("Failed to initialize storage: %v", err)
+		}
+		defer st.Close()
+
+		emb, err := newEmbedder()
+		if err != nil {
+			log.Fatalf("Failed to initialize embeddings: %v", err)
+		}
+		ponsAPI := api.NewAPI(st, emb)
+
+		checks := []struct {
+			field    string
+			criteria storage.IncompleteCriteria
+		}{
+			{"title", storage.IncompleteCriteria{Title: true}},
+			{"description", storage.IncompleteCriteria{Description: true}},
+			{"context", storage.IncompleteCriteria{Context: true}},
+		}
+
+		complete := true
+		for _, check := range checks {
+			docs, err := ponsAPI.ListIncomplete(check.criteria)
+			if err != nil {
+				log.Fatalf("Failed to audit %s: %v", check.field, err)
+			}
+			if len(docs) == 0 {
+				continue
+			}
+			complete = false
+
+			fmt.Printf("Missing %s: %d document(s)\n", check.field, len(docs))
+			for i, doc := range docs {
+				if i >= samples {
+					fmt.Printf("  ... and %d more\n", len(docs)-samples)
+					break
+				}
+				fmt.Printf("  - %s\n", doc.URL)
+			}
+			fmt.Println()
+		}
+
+		if complete {
+			fmt.Println("\033[32m✓ All documents have a title, description, and context.\033[0m")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+	auditCmd.Flags().IntP("samples", "s", 5, "Number of sample URLs to show per missing field")
+}
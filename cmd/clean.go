@@ -5,10 +5,10 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"github.com/tesh254/pons/internal/storage"
 )
 
@@ -30,8 +30,7 @@ var cleanCmd = &cobra.Command{
 			return
 		}
 
-		home, _ := os.UserHomeDir()
-		dbPath := filepath.Join(home, ".pons_data", "pons.db")
+		dbPath := viper.GetString("db")
 
 		st, err := storage.NewStorage(dbPath)
 		if err != nil {
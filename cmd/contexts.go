@@ -7,7 +7,6 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/tesh254/pons/internal/api"
-	"github.com/tesh254/pons/internal/llm"
 	"github.com/tesh254/pons/internal/storage"
 )
 
@@ -16,7 +15,6 @@ var contextsCmd = &cobra.Command{
 	Short: "Lists all unique contexts in the knowledge base",
 	Run: func(cmd *cobra.Command, args []string) {
 		dbPath := viper.GetString("db")
-		workerURL := viper.GetString("worker-url") // workerURL is needed for API initialization
 
 		// Initialize storage
 		st, err := storage.NewStorage(dbPath)
@@ -26,7 +24,10 @@ var contextsCmd = &cobra.Command{
 		defer st.Close()
 
 		// Initialize LLM (even if not directly used by GetContexts, API requires it)
-		emb := llm.NewEmbeddings(workerURL)
+		emb, err := newEmbedder()
+		if err != nil {
+			log.Fatalf("Failed to initialize embeddings: %v", err)
+		}
 
 		// Initialize API
 		ponsAPI := api.NewAPI(st, emb)
@@ -3,24 +3,26 @@ package cmd
 import (
 	"fmt"
 	"log"
-	"os"
-	"path/filepath"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"github.com/tesh254/pons/internal/api"
-	"github.com/tesh254/pons/internal/llm"
 	"github.com/tesh254/pons/internal/storage"
 )
 
 var deleteCmd = &cobra.Command{
 	Use:   "delete [url]",
 	Short: "Deletes a document from the database",
-	Args:  cobra.ExactArgs(1),
+	Long: `Deletes a document, and every document stored under its URL, from the database.
+
+With --seed, deletes everything stored by crawls started from the given URL instead.`,
+	Args: cobra.MaximumNArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		url := args[0]
-		home, _ := os.UserHomeDir()
-		dbPath := filepath.Join(home, ".pons_data", "pons.db")
-		workerURL := "https://vectors.madebyknnls.com"
+		seed, _ := cmd.Flags().GetString("seed")
+		if (len(args) == 1) == (seed != "") {
+			log.Fatalf("Pass either a document URL or --seed")
+		}
+		dbPath := viper.GetString("db")
 
 		st, err := storage.NewStorage(dbPath)
 		if err != nil {
@@ -28,10 +30,24 @@ var deleteCmd = &cobra.Command{
 		}
 		defer st.Close()
 
-		emb := llm.NewEmbeddings(workerURL)
+		emb, err := newEmbedder()
+		if err != nil {
+			log.Fatalf("Failed to initialize embeddings: %v", err)
+		}
 		ponsAPI := api.NewAPI(st, emb)
 
 		context, _ := cmd.Flags().GetString("context") // Retrieve context flag
+
+		if seed != "" {
+			deleted, err := ponsAPI.DeleteBySeed(seed, context)
+			if err != nil {
+				log.Fatalf("Failed to delete documents: %v", err)
+			}
+			fmt.Printf("Deleted %d documents crawled from '%s'.\n", deleted, seed)
+			return
+		}
+
+		url := args[0]
 		if err := ponsAPI.DeleteDocument(url, context); err != nil {
 			log.Fatalf("Failed to delete document: %v", err)
 		}
@@ -43,4 +59,5 @@ var deleteCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(deleteCmd)
 	deleteCmd.Flags().StringP("context", "c", "", "Context of the document to delete")
+	deleteCmd.Flags().String("seed", "", "Delete every document from crawls started at this URL")
 }
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tesh254/pons/internal/api"
+	"github.com/tesh254/pons/internal/storage"
+)
+
+var deleteContextCmd = &cobra.Command{
+	Use:   "delete-context [name]",
+	Short: "Deletes every document in a context",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		context := args[0]
+		if context == "" {
+			log.Fatalf("Context name must not be empty")
+		}
+
+		st, err := storage.NewStorage(viper.GetString("db"))
+		if err != nil {
+			log.Fatalf("Failed to initialize storage: %v", err)
+		}
+		defer st.Close()
+
+		emb, err := newEmbedder()
+		if err != nil {
+			log.Fatalf("Failed to initialize embeddings: %v", err)
+		}
+		ponsAPI := api.NewAPI(st, emb)
+
+		count, err := ponsAPI.CountDocuments(storage.SearchFilter{Context: context})
+		if err != nil {
+			log.Fatalf("Failed to count documents: %v", err)
+		}
+		if count == 0 {
+			fmt.Printf("No documents found in context '%s'.\n", context)
+			return
+		}
+
+		reader := bufio.NewReader(os.Stdin)
+		fmt.Printf("\033[31mWARNING: This will delete %d documents in context '%s' and is not recoverable.\033[0m\n", count, context)
+		fmt.Print("Are you sure you want to continue? (yes/no): ")
+
+		response, err := reader.ReadString('\n')
+		if err != nil {
+			log.Fatalf("Failed to read response: %v", err)
+		}
+
+		if strings.TrimSpace(strings.ToLower(response)) != "yes" {
+			fmt.Println("Delete operation cancelled.")
+			return
+		}
+
+		deleted, err := ponsAPI.DeleteByContext(context)
+		if err != nil {
+			log.Fatalf("Failed to delete context: %v", err)
+		}
+
+		fmt.Printf("Deleted %d documents from context '%s'.\n", deleted, context)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(deleteContextCmd)
+}
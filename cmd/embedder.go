@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/viper"
+	"github.com/tesh254/pons/internal/llm"
+	"github.com/tesh254/pons/internal/scraper"
+)
+
+// newEmbedder creates the embedder selected by --embedding-provider.
+//
+// The worker provider posts to --worker-url. The openai provider posts to the
+// OpenAI-compatible API at --embedding-base-url with --embedding-model, authenticating
+// with PONS_EMBEDDING_API_KEY, or OPENAI_API_KEY when that is unset. Both honor
+// --embedding-timeout, --embedding-retries, --embedding-max-chars, --embedding-overflow
+// and --user-agent, and --proxy when --proxy-embeddings is set.
+func newEmbedder() (llm.Embedder, error) {
+	config := llm.DefaultEmbeddingsConfig()
+	config.Timeout = viper.GetDuration("embedding-timeout")
+	config.MaxRetries = viper.GetInt("embedding-retries")
+	config.UserAgent = viper.GetString("user-agent")
+	config.MaxInputChars = viper.GetInt("embedding-max-chars")
+	switch overflow := viper.GetString("embedding-overflow"); overflow {
+	case "", "error":
+	case "truncate":
+		config.TruncateInput = true
+	default:
+		return nil, fmt.Errorf("unknown embedding overflow behavior %q (use error or truncate)", overflow)
+	}
+	if proxy := viper.GetString("proxy"); proxy != "" && viper.GetBool("proxy-embeddings") {
+		proxyURL, err := scraper.ParseProxyURL(proxy)
+		if err != nil {
+			return nil, err
+		}
+		config.Proxy = proxyURL
+	}
+
+	switch provider := viper.GetString("embedding-provider"); provider {
+	case "", "worker":
+		workerURL := viper.GetString("worker-url")
+		if workerURL == "" {
+			return nil, fmt.Errorf("worker-url is required for the worker embedding provider")
+		}
+		return llm.NewEmbeddingsWithConfig(workerURL, config), nil
+	case "openai":
+		model := viper.GetString("embedding-model")
+		if model == "" {
+			return nil, fmt.Errorf("embedding-model is required for the openai embedding provider")
+		}
+		apiKey := viper.GetString("embedding-api-key")
+		if apiKey == "" {
+			apiKey = os.Getenv("OPENAI_API_KEY")
+		}
+		return llm.NewOpenAIEmbeddingsWithConfig(viper.GetString("embedding-base-url"), model, apiKey, config), nil
+	default:
+		return nil, fmt.Errorf("unknown embedding provider %q (use worker or openai)", provider)
+	}
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tesh254/pons/internal/storage"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Exports documents as JSON Lines for backup or sharing",
+	Long: `Exports documents, one JSON object per line, including their embeddings.
+
+The dump is written to stdout unless --out is given, and can be loaded back with pons import.
+Pass --no-embeddings for a smaller, human-readable dump.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		context, _ := cmd.Flags().GetString("context")
+		outPath, _ := cmd.Flags().GetString("out")
+		noEmbeddings, _ := cmd.Flags().GetBool("no-embeddings")
+
+		st, err := storage.NewStorage(viper.GetString("db"))
+		if err != nil {
+			log.Fatalf("Failed to initialize storage: %v", err)
+		}
+		defer st.Close()
+
+		var out io.Writer = os.Stdout
+		if outPath != "" {
+			file, err := os.Create(outPath)
+			if err != nil {
+				log.Fatalf("Failed to create %s: %v", outPath, err)
+			}
+			defer file.Close()
+			out = file
+		}
+
+		w := bufio.NewWriter(out)
+		if err := st.Export(w, context, !noEmbeddings); err != nil {
+			log.Fatalf("Failed to export documents: %v", err)
+		}
+		if err := w.Flush(); err != nil {
+			log.Fatalf("Failed to write export: %v", err)
+		}
+
+		if outPath != "" {
+			fmt.Printf("\033[32m✓ Exported documents to %s\033[0m\n", outPath)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().StringP("context", "c", "", "Only export documents in this context")
+	exportCmd.Flags().StringP("out", "o", "", "File to write the dump to (default stdout)")
+	exportCmd.Flags().Bool("no-embeddings", false, "Leave out embeddings for a smaller, human-readable dump")
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tesh254/pons/internal/storage"
+)
+
+var exportMdCmd = &cobra.Command{
+	Use:   "export-md [context] [output_file]",
+	Short: "Exports all documents in a context as a single Markdown file",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		context := args[0]
+		outPath := args[1]
+
+		dbPath := viper.GetString("db")
+
+		st, err := storage.NewStorage(dbPath)
+		if err != nil {
+			log.Fatalf("Failed to initialize storage: %v", err)
+		}
+		defer st.Close()
+
+		file, err := os.Create(outPath)
+		if err != nil {
+			log.Fatalf("Failed to create %s: %v", outPath, err)
+		}
+		defer file.Close()
+
+		w := bufio.NewWriter(file)
+		fmt.Fprintf(w, "# %s\n", context)
+
+		count := 0
+		err = st.EachDocument(context, func(doc *storage.Document) error {
+			title := doc.Title
+			if title == "" {
+				title = doc.URL
+			}
+			fmt.Fprintf(w, "\n---\n\n## %s\n\nSource: %s\n\n", title, doc.URL)
+			if doc.Description != "" {
+				fmt.Fprintf(w, "> %s\n\n", doc.Description)
+			}
+			fmt.Fprintln(w, strings.TrimSpace(doc.Content))
+			count++
+			return nil
+		})
+		if err != nil {
+			log.Fatalf("Failed to export documents: %v", err)
+		}
+
+		if err := w.Flush(); err != nil {
+			log.Fatalf("Failed to write %s: %v", outPath, err)
+		}
+
+		if count == 0 {
+			fmt.Printf("No documents found in context '%s'.\n", context)
+			return
+		}
+		fmt.Printf("\033[32m✓ Exported %d document(s) to %s\033[0m\n", count, outPath)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportMdCmd)
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tesh254/pons/internal/api"
+	"github.com/tesh254/pons/internal/storage"
+)
+
+var getCmd = &cobra.Command{
+	Use:     "get [url]",
+	Aliases: []string{"show"},
+	Short:   "Prints a stored document",
+	Long: `Prints a single stored document, including its full Markdown content. Useful for
+checking what a crawl actually stored.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dbPath := viper.GetString("db")
+
+		st, err := storage.NewStorage(dbPath)
+		if err != nil {
+			log.Fatalf("Failed to initialize storage: %v", err)
+		}
+		defer st.Close()
+
+		context, _ := cmd.Flags().GetString("context")
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+
+		doc, err := api.NewAPI(st, nil).GetDocument(args[0], context)
+		if err != nil {
+			log.Fatalf("Failed to get document %s: %v", args[0], err)
+		}
+
+		if jsonOutput {
+			// Embeddings are large and not useful when inspecting a document
+			doc.Embeddings = nil
+			out, err := json.MarshalIndent(doc, "", "  ")
+			if err != nil {
+				log.Fatalf("Failed to encode document: %v", err)
+			}
+			fmt.Println(string(out))
+			return
+		}
+
+		fmt.Printf("URL: %s\nTitle: %s\n", doc.URL, doc.Title)
+		if doc.Description != "" {
+			fmt.Printf("Description: %s\n", doc.Description)
+		}
+		fmt.Printf("Context: %s\nSource Type: %s\n", doc.Context, doc.SourceType)
+		if doc.Language != "" {
+			fmt.Printf("Language: %s\n", doc.Language)
+		}
+		if doc.SeedURL != "" {
+			fmt.Printf("Seed URL: %s\n", doc.SeedURL)
+		}
+		if len(doc.Tags) > 0 {
+			fmt.Printf("Tags: %s\n", strings.Join(doc.Tags, ", "))
+		}
+		fmt.Printf("Checksum: %s\n\n%s\n", doc.Checksum, doc.Content)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(getCmd)
+	getCmd.Flags().StringP("context", "c", "", "Only look for the document in this context")
+	getCmd.Flags().Bool("json", false, "Print the document as JSON")
+}
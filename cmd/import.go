@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tesh254/pons/internal/llm"
+	"github.com/tesh254/pons/internal/storage"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import [file]",
+	Short: "Imports documents from a JSON Lines dump",
+	Long: `Imports documents written by pons export, replacing stored documents with the same URL.
+
+All documents are imported in a single transaction. Malformed lines are skipped and counted
+instead of aborting the import. Documents without embeddings are stored as they are unless
+--embed-missing is passed, in which case their embeddings are generated during the import.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		inPath := args[0]
+		embedMissing, _ := cmd.Flags().GetBool("embed-missing")
+
+		file, err := os.Open(inPath)
+		if err != nil {
+			log.Fatalf("Failed to open %s: %v", inPath, err)
+		}
+		defer file.Close()
+
+		st, err := storage.NewStorage(viper.GetString("db"))
+		if err != nil {
+			log.Fatalf("Failed to initialize storage: %v", err)
+		}
+		defer st.Close()
+
+		var prepare func(*storage.Document) error
+		if embedMissing {
+			emb, err := newEmbedder()
+			if err != nil {
+				log.Fatalf("Failed to initialize embeddings: %v", err)
+			}
+			embeddingTemplate := viper.GetString("embedding-template")
+
+			prepare = func(doc *storage.Document) error {
+				if len(doc.Embeddings) > 0 {
+					return nil
+				}
+				input, err := llm.RenderEmbeddingInput(embeddingTemplate, llm.EmbeddingInput{Title: doc.Title, Context: doc.Context, Content: doc.Content})
+				if err != nil {
+					return err
+				}
+				doc.Embeddings, err = emb.GenerateEmbeddings(input)
+				if err != nil {
+					log.Printf("Warning: failed to generate embeddings for %s: %v", doc.URL, err)
+					return err
+				}
+				doc.EmbeddingModel = emb.Model()
+				return nil
+			}
+		}
+
+		imported, skipped, err := st.Import(file, prepare)
+		if err != nil {
+			log.Fatalf("Failed to import documents: %v", err)
+		}
+
+		fmt.Printf("\033[32m✓ Imported %d document(s) from %s\033[0m\n", imported, inPath)
+		if skipped > 0 {
+			fmt.Printf("Skipped %d line(s) that could not be imported.\n", skipped)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.Flags().Bool("embed-missing", false, "Generate embeddings for imported documents that have none")
+}
@@ -1,12 +1,15 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
-	"os"
-	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tesh254/pons/internal/api"
+	"github.com/tesh254/pons/internal/scraper"
 	"github.com/tesh254/pons/internal/storage"
 )
 
@@ -14,8 +17,7 @@ var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "Lists all documents in the database",
 	Run: func(cmd *cobra.Command, args []string) {
-		home, _ := os.UserHomeDir()
-		dbPath := filepath.Join(home, ".pons_data", "pons.db")
+		dbPath := viper.GetString("db")
 
 		st, err := storage.NewStorage(dbPath)
 		if err != nil {
@@ -23,22 +25,79 @@ var listCmd = &cobra.Command{
 		}
 		defer st.Close()
 
-		docs, err := st.ListDocuments("", 1000)
+		context, _ := cmd.Flags().GetString("context")
+		limit, _ := cmd.Flags().GetInt("limit")
+		offset, _ := cmd.Flags().GetInt("offset")
+		seed, _ := cmd.Flags().GetString("seed")
+		sourceType, _ := cmd.Flags().GetString("source-type")
+		language, _ := cmd.Flags().GetString("language")
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+		tagValues, _ := cmd.Flags().GetStringArray("tag")
+		includeContent, _ := cmd.Flags().GetBool("include-content")
+		includeEmbeddings, _ := cmd.Flags().GetBool("include-embeddings")
+		filter := storage.SearchFilter{Context: context, Language: scraper.NormalizeLanguage(language), SeedURL: seed, SourceType: sourceType, Tags: parseTags(tagValues)}
+
+		total, err := st.CountDocuments(filter)
+		if err != nil {
+			log.Fatalf("Failed to count documents: %v", err)
+		}
+
+		docs, err := st.ListDocuments(filter, limit, offset)
 		if err != nil {
 			log.Fatalf("Failed to list documents: %v", err)
 		}
 
+		if jsonOutput {
+			// Content and embeddings are large and usually not needed in a listing
+			api.TrimDocuments(docs, includeContent, includeEmbeddings)
+			out, err := json.MarshalIndent(map[string]interface{}{"documents": docs, "total": total}, "", "  ")
+			if err != nil {
+				log.Fatalf("Failed to encode documents: %v", err)
+			}
+			fmt.Println(string(out))
+			return
+		}
+
 		if len(docs) == 0 {
 			fmt.Println("No documents found.")
 			return
 		}
 
 		for _, doc := range docs {
-			fmt.Printf("URL: %s\nSource Type: %s\nChecksum: %s\nContent Length: %d\nEmbeddings Length: %d\n\n", doc.URL, doc.SourceType, doc.Checksum, len(doc.Content), len(doc.Embeddings))
+			fmt.Printf("URL: %s\nSource Type: %s\n", doc.URL, doc.SourceType)
+			if doc.Language != "" {
+				fmt.Printf("Language: %s\n", doc.Language)
+			}
+			if doc.SeedURL != "" {
+				fmt.Printf("Seed URL: %s\n", doc.SeedURL)
+			}
+			if len(doc.Tags) > 0 {
+				fmt.Printf("Tags: %s\n", strings.Join(doc.Tags, ", "))
+			}
+			fmt.Printf("Checksum: %s\nContent Length: %d\nEmbeddings Length: %d\n", doc.Checksum, len(doc.Content), len(doc.Embeddings))
+			if includeEmbeddings {
+				fmt.Printf("Embeddings: %v\n", doc.Embeddings)
+			}
+			if includeContent {
+				fmt.Printf("Content:\n%s\n", doc.Content)
+			}
+			fmt.Println()
 		}
+		fmt.Printf("Showing %d-%d of %d documents.\n", offset+1, offset+len(docs), total)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(listCmd)
+	listCmd.Flags().StringP("context", "c", "", "Only list documents in this context")
+	listCmd.Flags().IntP("limit", "n", 1000, "Maximum number of documents to list")
+	listCmd.Flags().Int("offset", 0, "Number of documents to skip")
+	listCmd.Flags().String("seed", "", "Only list documents from crawls started at this URL")
+	listCmd.Flags().String("source-type", "", "Only list documents of this source type (web_scrape, file_read or pdf)")
+	listCmd.Flags().String("language", "", "Only list documents in this language (e.g., 'en'); --lang also works")
+	listCmd.Flags().SetNormalizeFunc(languageFlagAlias)
+	listCmd.Flags().StringArray("tag", nil, "Only list documents carrying this tag (repeatable; all must match)")
+	listCmd.Flags().Bool("json", false, "Print the documents as JSON")
+	listCmd.Flags().Bool("include-content", false, "Also print each document's content")
+	listCmd.Flags().Bool("include-embeddings", false, "Also print each document's embedding vector")
 }
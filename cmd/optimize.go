@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tesh254/pons/internal/storage"
+)
+
+var optimizeCmd = &cobra.Command{
+	Use:     "optimize",
+	Aliases: []string{"vacuum"},
+	Short:   "Reclaims unused space in the database",
+	Long: `Vacuums the database, refreshes SQLite's query planner statistics and truncates the
+write-ahead log. Run it after a large delete or clean to shrink the database file.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		dbPath := viper.GetString("db")
+
+		st, err := storage.NewStorage(dbPath)
+		if err != nil {
+			log.Fatalf("Failed to initialize storage: %v", err)
+		}
+		defer st.Close()
+
+		before := databaseSize(dbPath)
+		if err := st.Optimize(); err != nil {
+			log.Fatalf("Failed to optimize database: %v", err)
+		}
+		after := databaseSize(dbPath)
+
+		fmt.Printf("Optimized database: %s -> %s (%s reclaimed).\n", formatSize(before), formatSize(after), formatSize(max(before-after, 0)))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(optimizeCmd)
+}
+
+// databaseSize returns the combined size of the database file and its write-ahead log.
+func databaseSize(dbPath string) int64 {
+	var size int64
+	for _, path := range []string{dbPath, dbPath + "-wal"} {
+		if info, err := os.Stat(path); err == nil {
+			size += info.Size()
+		}
+	}
+	return size
+}
+
+// formatSize formats a byte count for humans, e.g. "1.5 MB".
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
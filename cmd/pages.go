@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/tesh254/pons/internal/storage"
+)
+
+// storedPages groups the stored documents fetched from the web with one of sourceTypes,
+// e.g. web_scrape pages and the PDFs linked from them, by the page URL they were cut from,
+// optionally only in contextName. Embeddings are dropped, since callers only need the
+// metadata.
+func storedPages(st *storage.Storage, contextName string, sourceTypes ...string) (map[string][]*storage.Document, error) {
+	pages := make(map[string][]*storage.Document)
+	err := st.EachDocument(contextName, func(doc *storage.Document) error {
+		if !slices.Contains(sourceTypes, doc.SourceType) {
+			return nil
+		}
+		if !strings.HasPrefix(doc.URL, "http://") && !strings.HasPrefix(doc.URL, "https://") {
+			return nil
+		}
+		page, _, _ := strings.Cut(doc.URL, "#")
+		doc.Embeddings = nil // Not needed, and large
+		pages[page] = append(pages[page], doc)
+		return nil
+	})
+	return pages, err
+}
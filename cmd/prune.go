@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tesh254/pons/internal/api"
+	"github.com/tesh254/pons/internal/scraper"
+	"github.com/tesh254/pons/internal/storage"
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Deletes stored web pages that no longer exist",
+	Long: `Checks the page behind every stored web_scrape document and deletes the documents of
+pages that now answer 404 Not Found or 410 Gone, including their chunks and sections.
+
+Pages are checked with a HEAD request (GET when HEAD isn't supported), spaced by
+--request-delay per host like a crawl. Pages that fail to answer or return any other
+status are kept. Use --dry-run to only list the pages that would be deleted.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		contextName, _ := cmd.Flags().GetString("context")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		requestDelay, _ := cmd.Flags().GetDuration("request-delay")
+		if requestDelay < 0 {
+			log.Fatalf("--request-delay must not be negative, got %s", requestDelay)
+		}
+
+		st, err := storage.NewStorage(viper.GetString("db"))
+		if err != nil {
+			log.Fatalf("Failed to initialize storage: %v", err)
+		}
+		defer st.Close()
+		ponsAPI := api.NewAPI(st, nil)
+
+		pages, err := storedPages(st, contextName, "web_scrape")
+		if err != nil {
+			log.Fatalf("Failed to list documents: %v", err)
+		}
+		if len(pages) == 0 {
+			fmt.Println("No stored web documents to check.")
+			return
+		}
+
+		urls := make([]string, 0, len(pages))
+		for page := range pages {
+			urls = append(urls, page)
+		}
+		sort.Strings(urls)
+
+		config := scraper.DefaultConfig()
+		config.UserAgent = viper.GetString("user-agent")
+		config.ProxyURL = viper.GetString("proxy")
+		config.RequestDelay = requestDelay
+		s, err := scraper.New(urls[0], config)
+		if err != nil {
+			log.Fatalf("Failed to initialize scraper: %v", err)
+		}
+
+		pruned, failed := 0, 0
+		for _, page := range urls {
+			status, err := s.CheckStatus(cmd.Context(), page)
+			if err != nil {
+				failed++
+				log.Printf("Warning: failed to check %s: %v", page, err)
+				continue
+			}
+			if status != http.StatusNotFound && status != http.StatusGone {
+				if verbose {
+					fmt.Printf("  - Kept %s (%d)\n", page, status)
+				}
+				continue
+			}
+
+			if dryRun {
+				pruned++
+				fmt.Printf("  - Would prune %s (%d)\n", page, status)
+				continue
+			}
+			deleted := true
+			for _, doc := range pages[page] {
+				if err := ponsAPI.DeleteStaleChunks(doc.URL, doc.Context, nil); err != nil {
+					log.Printf("Warning: failed to delete %s: %v", doc.URL, err)
+					deleted = false
+				}
+			}
+			if !deleted {
+				failed++
+				continue
+			}
+			pruned++
+			fmt.Printf("  - Pruned %s (%d)\n", page, status)
+		}
+
+		if dryRun {
+			fmt.Printf("Checked %d pages: %d would be pruned, %d failed.\n", len(urls), pruned, failed)
+			return
+		}
+		fmt.Printf("Checked %d pages: %d pruned, %d failed.\n", len(urls), pruned, failed)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pruneCmd)
+	pruneCmd.Flags().StringP("context", "c", "", "Only check documents in this context")
+	pruneCmd.Flags().Bool("dry-run", false, "List the pages that would be pruned without deleting anything")
+	pruneCmd.Flags().Duration("request-delay", scraper.DefaultConfig().RequestDelay, "Minimum time between requests to the same host")
+	pruneCmd.Flags().BoolP("verbose", "v", false, "Print the status of every page checked")
+}
@@ -0,0 +1,275 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tesh254/pons/internal/api"
+	"github.com/tesh254/pons/internal/llm"
+	"github.com/tesh254/pons/internal/scraper"
+	"github.com/tesh254/pons/internal/storage"
+)
+
+var refreshCmd = &cobra.Command{
+	Use:     "refresh [url]",
+	Aliases: []string{"rescrape"},
+	Short:   "Re-fetches stored web pages and re-embeds the ones that changed",
+	Long: `Re-fetches the pages behind stored web_scrape documents and refreshes them in place.
+
+Each page is fetched again, converted and chunked the same way add does, and only chunks
+whose content changed are re-embedded and stored; chunks and sections the page no longer
+has are removed. Pages stored with --sections are split into sections again. Titles and
+descriptions are taken from the fresh page, while tags, seed URL and crawl depth are kept.
+
+Pages added with --header, --basic-auth, --cookie, --cookie-file or --render-url need the
+same flags again to be refreshed, since they are not stored with the documents.
+
+Without a URL every web page in --context (or in every context) is refreshed. With a URL,
+only the documents of that page are.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		contextName, _ := cmd.Flags().GetString("context")
+		chunkSize, _ := cmd.Flags().GetInt("chunk-size")
+		chunkOverlap, _ := cmd.Flags().GetInt("chunk-overlap")
+		verbose, _ := cmd.Flags().GetBool("verbose")
+
+		st, err := storage.NewStorage(viper.GetString("db"))
+		if err != nil {
+			log.Fatalf("Failed to initialize storage: %v", err)
+		}
+		defer st.Close()
+
+		emb, err := newEmbedder()
+		if err != nil {
+			log.Fatalf("Failed to initialize embeddings: %v", err)
+		}
+		ponsAPI := api.NewAPI(st, emb)
+
+		pages, err := storedPages(st, contextName, "web_scrape", "pdf")
+		if err != nil {
+			log.Fatalf("Failed to list documents: %v", err)
+		}
+
+		if len(args) == 1 {
+			page, _, _ := strings.Cut(args[0], "#")
+			docs, ok := pages[page]
+			if !ok {
+				log.Fatalf("No stored web documents for %s", page)
+			}
+			pages = map[string][]*storage.Document{page: docs}
+		}
+		if len(pages) == 0 {
+			fmt.Println("No stored web documents to refresh.")
+			return
+		}
+
+		urls := make([]string, 0, len(pages))
+		for page := range pages {
+			urls = append(urls, page)
+		}
+		sort.Strings(urls)
+
+		// Every page is fetched with the same settings; only the URL changes
+		config := scraper.DefaultConfig()
+		config.UserAgent = viper.GetString("user-agent")
+		config.ProxyURL = viper.GetString("proxy")
+		config.MaxDepth = 0
+		config.MaxPages = 1
+		config.ChunkSize = chunkSize
+		config.ChunkOverlap = chunkOverlap
+		config.OnPage = func(string, int, error) {} // Errors are returned by the crawl
+		applyAccessFlags(cmd, config)
+
+		refresher := &pageRefresher{
+			config:            config,
+			api:               ponsAPI,
+			storage:           st,
+			embedder:          emb,
+			embeddingTemplate: viper.GetString("embedding-template"),
+		}
+		unchangedPages, updated, failed := 0, 0, 0
+		for _, page := range urls {
+			changed, err := refresher.refresh(cmd.Context(), page, pages[page])
+			switch {
+			case err != nil:
+				failed++
+				log.Printf("Warning: failed to refresh %s: %v", page, err)
+			case changed:
+				updated++
+				if verbose {
+					fmt.Printf("  - Updated %s\n", page)
+				}
+			default:
+				unchangedPages++
+				if verbose {
+					fmt.Printf("  - Unchanged: %s\n", page)
+				}
+			}
+		}
+
+		fmt.Printf("Checked %d pages: %d unchanged, %d updated, %d failed.\n", len(urls), unchangedPages, updated, failed)
+	},
+}
+
+// pageRefresher re-fetches stored pages and stores what changed.
+type pageRefresher struct {
+	// config is copied for every page fetched
+	config            *scraper.Config
+	api               *api.API
+	storage           *storage.Storage
+	embedder          llm.Embedder
+	embeddingTemplate string
+}
+
+// refresh re-fetches page, whose stored documents are docs, and re-embeds and stores the
+// chunks that changed. It reports whether anything was stored or removed.
+func (r *pageRefresher) refresh(ctx context.Context, page string, docs []*storage.Document) (bool, error) {
+	config := *r.config
+	s, err := scraper.New(page, &config)
+	if err != nil {
+		return false, err
+	}
+	if err := s.CrawlWithContext(ctx); err != nil {
+		return false, err
+	}
+	var path, content string
+	for p, c := range s.SubPathsHTMLContent {
+		path, content = p, c
+	}
+	if content == "" {
+		return false, fmt.Errorf("no content fetched")
+	}
+
+	// Pages stored as sections have anchored documents
+	first, withSections := docs[0], false
+	stored := make(map[string]*storage.Document, len(docs))
+	for _, doc := range docs {
+		stored[doc.URL] = doc
+		if doc.Anchor != "" {
+			withSections = true
+		}
+	}
+
+	// The page's own title and description may have changed too. Pages are stored with
+	// the starting page's metadata when they have none, so keep that in that case.
+	metadata := s.SubPathsMetadata[path]
+	title, description := strings.TrimSpace(metadata.Title), strings.TrimSpace(metadata.Description)
+	if title == "" {
+		title = first.Title
+	}
+	if description == "" {
+		description = first.Description
+	}
+	parser := scraper.Parser{StripSelectors: config.StripSelectors}
+	var sections []scraper.Section
+	if withSections {
+		sections, err = parser.ToSections(content)
+	} else {
+		var markdown string
+		markdown, err = parser.ToMarkdown(content)
+		sections = []scraper.Section{{Markdown: markdown}}
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to convert HTML to markdown: %v", err)
+	}
+
+	sourceType := "web_scrape"
+	if s.SubPathsPDF[path] {
+		sourceType = "pdf"
+	}
+
+	keep := make(map[string]bool)
+	var changed []*storage.Document
+	var inputs []string
+	for _, section := range sections {
+		if strings.TrimSpace(section.Markdown) == "" {
+			continue
+		}
+		sectionURL := page
+		if section.Anchor != "" {
+			sectionURL = page + "#" + section.Anchor
+		}
+		chunks := scraper.ChunkMarkdown(section.Markdown, config.ChunkSize, config.ChunkOverlap)
+		chunkURLs := scraper.ChunkURLs(sectionURL, len(chunks))
+		for i, chunk := range chunks {
+			keep[chunkURLs[i]] = true
+			checksum := fmt.Sprintf("%x", sha256.Sum256([]byte(chunk)))
+			// The title is part of the embedding input, so a new title re-embeds the chunk too
+			if old := stored[chunkURLs[i]]; old != nil && old.Title == title && old.Description == description && unchanged(r.storage, chunkURLs[i], first.Context, checksum) {
+				continue
+			}
+			input, err := llm.RenderEmbeddingInput(r.embeddingTemplate, llm.EmbeddingInput{Title: title, Context: first.Context, Content: chunk})
+			if err != nil {
+				return false, err
+			}
+			inputs = append(inputs, input)
+			changed = append(changed, &storage.Document{
+				URL:          chunkURLs[i],
+				Title:        title,
+				Description:  description,
+				Content:      chunk,
+				Checksum:     checksum,
+				Context:      first.Context,
+				SourceType:   sourceType,
+				Anchor:       section.Anchor,
+				Language:     s.SubPathsLanguage[path],
+				ETag:         s.SubPathsValidators[path].ETag,
+				LastModified: s.SubPathsValidators[path].LastModified,
+				Depth:        first.Depth,
+				SeedURL:      first.SeedURL,
+				FetchURL:     first.FetchURL,
+				Tags:         first.Tags,
+			})
+		}
+	}
+	if len(keep) == 0 {
+		return false, fmt.Errorf("page has no content")
+	}
+
+	if len(changed) > 0 {
+		embeddings, err := llm.GenerateEmbeddingsBatch(r.embedder, inputs)
+		if err != nil {
+			return false, fmt.Errorf("failed to generate embeddings: %v", err)
+		}
+		for i, doc := range changed {
+			doc.Embeddings = embeddings[i]
+		}
+		if err := r.api.UpsertDocuments(changed); err != nil {
+			return false, err
+		}
+	}
+
+	// Drop the chunks and sections the page no longer has. The rows just stored are
+	// passed as kept, since a stale page URL's chunk pattern also matches them.
+	kept := make([]string, 0, len(keep))
+	for url := range keep {
+		kept = append(kept, url)
+	}
+	removed := false
+	for _, doc := range docs {
+		if keep[doc.URL] {
+			continue
+		}
+		if err := r.api.DeleteStaleChunks(doc.URL, doc.Context, kept); err != nil {
+			return false, err
+		}
+		removed = true
+	}
+
+	return len(changed) > 0 || removed, nil
+}
+
+func init() {
+	rootCmd.AddCommand(refreshCmd)
+	addAccessFlags(refreshCmd)
+	refreshCmd.Flags().StringP("context", "c", "", "Only refresh documents in this context")
+	refreshCmd.Flags().Int("chunk-size", 2000, "Split pages longer than this many characters into overlapping chunks (0 stores whole pages)")
+	refreshCmd.Flags().Int("chunk-overlap", 200, "Characters shared between consecutive chunks")
+	refreshCmd.Flags().BoolP("verbose", "v", false, "Print the outcome for every page")
+}
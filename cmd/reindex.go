@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tesh254/pons/internal/api"
+	"github.com/tesh254/pons/internal/llm"
+	"github.com/tesh254/pons/internal/storage"
+)
+
+var reindexCmd = &cobra.Command{
+	Use:   "reindex",
+	Short: "Rebuilds search indexes from the stored documents",
+	Long: `Rebuilds search indexes from the documents table without re-fetching or re-embedding anything.
+
+With --structures, the SQLite indexes (and the full-text index, when present) are rebuilt
+from the stored rows, embeddings stored before pons normalized them are rescaled to unit
+length so searches can use the faster dot product, and every stored embedding is loaded
+into a throwaway vector index to check that it can be. Use this to recover after a manual
+database edit or an interrupted write.
+
+The in-memory vector index of a running "pons start --ann-index" server lives in that
+process; it notices writes to the database, including this command's, and rebuilds itself.
+
+With --embeddings, every document whose embeddings were produced by a different
+embedding model (or by an unknown one) is re-embedded from its stored content.
+Documents are processed in batches of --batch-size using --workers concurrent
+embedding requests, and each batch is committed in one transaction. Re-embedded
+documents are marked with the model that produced them, so an interrupted run
+picks up where it left off when started again.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		structures, _ := cmd.Flags().GetBool("structures")
+		embeddings, _ := cmd.Flags().GetBool("embeddings")
+		if !structures && !embeddings {
+			log.Fatalf("Nothing to rebuild: pass --embeddings to re-embed documents or --structures to rebuild the database indexes")
+		}
+
+		st, err := storage.NewStorage(viper.GetString("db"))
+		if err != nil {
+			log.Fatalf("Failed to initialize storage: %v", err)
+		}
+		defer st.Close()
+
+		if embeddings {
+			context, _ := cmd.Flags().GetString("context")
+			batchSize, _ := cmd.Flags().GetInt("batch-size")
+			workers, _ := cmd.Flags().GetInt("workers")
+
+			emb, err := newEmbedder()
+			if err != nil {
+				log.Fatalf("Failed to initialize embeddings: %v", err)
+			}
+			reembedded, failed, err := reembed(st, emb, context, batchSize, workers)
+			if err != nil {
+				log.Fatalf("Failed to re-embed documents: %v", err)
+			}
+			fmt.Printf("Re-embedded %d documents (%d failed).\n", reembedded, failed)
+			if failed > 0 {
+				fmt.Println("Run the command again to retry the failed documents.")
+			}
+			if !structures {
+				return
+			}
+		}
+
+		if err := st.RebuildIndexes(); err != nil {
+			log.Fatalf("Failed to rebuild database indexes: %v", err)
+		}
+		fmt.Println("Rebuilt database indexes.")
+
+		normalized, err := st.NormalizeEmbeddings()
+		if err != nil {
+			log.Fatalf("Failed to normalize embeddings: %v", err)
+		}
+		fmt.Printf("Normalized %d embeddings.\n", normalized)
+
+		// Building a vector index only reads stored embeddings, so no embeddings worker is
+		// needed; the index itself is discarded with this process
+		ponsAPI := api.NewAPI(st, nil)
+		vectors, err := ponsAPI.RebuildIndex()
+		if err != nil {
+			log.Fatalf("Failed to load embeddings into a vector index: %v", err)
+		}
+		fmt.Printf("Checked that all %d embeddings load into a vector index.\n", vectors)
+	},
+}
+
+// reembed regenerates the embeddings of every document not yet embedded by emb, batchSize
+// documents at a time with up to workers concurrent requests. Each batch is stored in one
+// transaction before the next is read. Documents that fail to embed are counted and left
+// for the next run.
+//
+// Re-embedding the whole store may change the embedding dimension, which is recorded once
+// every document has been re-embedded. Re-embedding a single context must keep the
+// store's dimension, since the other contexts keep their embeddings.
+func reembed(st *storage.Storage, emb llm.Embedder, context string, batchSize, workers int) (reembedded, failed int, err error) {
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	if workers <= 0 {
+		workers = 1
+	}
+
+	model := emb.Model()
+	embeddingTemplate := viper.GetString("embedding-template")
+
+	total, err := st.CountStaleEmbeddings(model, context)
+	if err != nil {
+		return 0, 0, err
+	}
+	if total == 0 {
+		fmt.Println("All embeddings are up to date.")
+		return 0, 0, nil
+	}
+	fmt.Printf("Re-embedding %d documents with %s...\n", total, model)
+
+	expected, err := st.EmbeddingDimension()
+	if err != nil {
+		return 0, 0, err
+	}
+	dimension := 0
+
+	after := ""
+	for {
+		batch, err := st.ListStaleEmbeddings(model, context, after, batchSize)
+		if err != nil {
+			return reembedded, failed, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		after = batch[len(batch)-1].URL
+
+		jobs := make(chan *storage.Document)
+		var mu sync.Mutex
+		var done []*storage.Document
+		var wg sync.WaitGroup
+
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for doc := range jobs {
+					input, err := llm.RenderEmbeddingInput(embeddingTemplate, llm.EmbeddingInput{Title: doc.Title, Context: doc.Context, Content: doc.Content})
+					if err == nil {
+						doc.Embeddings, err = emb.GenerateEmbeddings(input)
+					}
+					if err == nil && context != "" && expected > 0 && len(doc.Embeddings) != expected {
+						err = fmt.Errorf("got %d-dimensional embeddings but the database holds %d-dimensional ones; re-embed every context to change the dimension", len(doc.Embeddings), expected)
+					}
+
+					mu.Lock()
+					if err != nil {
+						log.Printf("Warning: failed to embed %s: %v", doc.URL, err)
+						failed++
+					} else {
+						doc.EmbeddingModel = model
+						dimension = len(doc.Embeddings)
+						done = append(done, doc)
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+		for _, doc := range batch {
+			jobs <- doc
+		}
+		close(jobs)
+		wg.Wait()
+
+		if len(done) > 0 {
+			if err := st.UpdateEmbeddings(done); err != nil {
+				return reembedded, failed, err
+			}
+		}
+		reembedded += len(done)
+		fmt.Printf("  %d/%d re-embedded, %d failed\n", reembedded, total, failed)
+	}
+
+	if context == "" && failed == 0 && dimension > 0 && dimension != expected {
+		if err := st.SetEmbeddingDimension(dimension); err != nil {
+			return reembedded, failed, err
+		}
+		fmt.Printf("Embedding dimension changed from %d to %d.\n", expected, dimension)
+	}
+	return reembedded, failed, nil
+}
+
+func init() {
+	rootCmd.AddCommand(reindexCmd)
+	reindexCmd.Flags().Bool("structures", false, "Rebuild the database indexes, normalize stored embeddings and check that they load into a vector index")
+	reindexCmd.Flags().Bool("embeddings", false, "Re-embed documents not yet embedded by the current embedding model")
+	reindexCmd.Flags().StringP("context", "c", "", "Only re-embed documents in this context")
+	reindexCmd.Flags().Int("batch-size", 100, "Number of documents re-embedded and committed per batch")
+	reindexCmd.Flags().Int("workers", 4, "Number of concurrent embedding requests")
+}
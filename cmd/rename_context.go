@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tesh254/pons/internal/api"
+	"github.com/tesh254/pons/internal/storage"
+)
+
+var renameContextCmd = &cobra.Command{
+	Use:   "rename-context [old] [new]",
+	Short: "Renames a context",
+	Long: `Moves every document in a context to a new context name.
+
+The rename is refused if the new context already has documents, unless --merge is passed.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		oldContext, newContext := args[0], args[1]
+		merge, _ := cmd.Flags().GetBool("merge")
+
+		st, err := storage.NewStorage(viper.GetString("db"))
+		if err != nil {
+			log.Fatalf("Failed to initialize storage: %v", err)
+		}
+		defer st.Close()
+
+		emb, err := newEmbedder()
+		if err != nil {
+			log.Fatalf("Failed to initialize embeddings: %v", err)
+		}
+		ponsAPI := api.NewAPI(st, emb)
+
+		if err := ponsAPI.RenameContext(oldContext, newContext, merge); err != nil {
+			log.Fatalf("Failed to rename context: %v", err)
+		}
+
+		fmt.Printf("Context '%s' renamed to '%s'.\n", oldContext, newContext)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(renameContextCmd)
+	renameContextCmd.Flags().Bool("merge", false, "Merge into the new context even if it already has documents")
+}
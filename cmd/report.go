@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// Reasons a crawled page did not end up in the knowledge base.
+const (
+	skipFetchFailed      = "fetch failed"
+	skipDuplicate        = "duplicate canonical URL"
+	skipConversionFailed = "markdown conversion failed"
+	skipThinContent      = "no text content"
+	skipEmbeddingFailed  = "embedding failed"
+	skipStoreFailed      = "store failed"
+	skipNotModified      = "not modified since last ingest"
+	skipUnchanged        = "content unchanged"
+	skipOutsideDepth     = "outside stored depth range"
+	skipNearDuplicate    = "near-duplicate of another page"
+)
+
+// ingestReport counts pages as they move through the add pipeline so the
+// command can show where crawled pages were dropped.
+type ingestReport struct {
+	crawled   int
+	converted int
+	embedded  int
+	stored    int
+	documents int
+	skipped   map[string]int
+}
+
+func newIngestReport() *ingestReport {
+	return &ingestReport{skipped: make(map[string]int)}
+}
+
+// skip records a page that was dropped for reason.
+func (r *ingestReport) skip(reason string) {
+	r.skipped[reason]++
+}
+
+// print writes the summary table, itemizing skip reasons when fewer pages were stored than crawled.
+func (r *ingestReport) print() {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.SetStyle(table.StyleLight)
+	t.SetTitle("Ingest Summary")
+	t.AppendHeader(table.Row{"Stage", "Pages"})
+	t.AppendRow(table.Row{"Crawled", r.crawled})
+	t.AppendRow(table.Row{"Converted", r.converted})
+	t.AppendRow(table.Row{"Embedded", r.embedded})
+	t.AppendRow(table.Row{"Stored", r.stored})
+
+	if r.stored < r.crawled {
+		reasons := make([]string, 0, len(r.skipped))
+		for reason := range r.skipped {
+			reasons = append(reasons, reason)
+		}
+		sort.Strings(reasons)
+
+		t.AppendSeparator()
+		for _, reason := range reasons {
+			t.AppendRow(table.Row{"Skipped: " + reason, r.skipped[reason]})
+		}
+	}
+
+	t.AppendFooter(table.Row{"Documents stored", fmt.Sprint(r.documents)})
+	t.Render()
+}
+
+// plannedPage is a page or file that a dry run would have stored.
+type plannedPage struct {
+	path   string
+	title  string
+	length int
+	chunks int
+}
+
+// printPlan writes the pages a dry run would have stored, with their title, Markdown
+// length and the number of documents they would be split into.
+func printPlan(pages []plannedPage) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.SetStyle(table.StyleLight)
+	t.SetTitle("Dry Run: Nothing Was Stored")
+	t.AppendHeader(table.Row{"Path", "Title", "Length", "Documents"})
+	length, chunks := 0, 0
+	for _, page := range pages {
+		t.AppendRow(table.Row{page.path, page.title, page.length, page.chunks})
+		length += page.length
+		chunks += page.chunks
+	}
+	t.AppendFooter(table.Row{fmt.Sprintf("%d pages", len(pages)), "", length, chunks})
+	t.Render()
+}
@@ -1,19 +1,17 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
-	"github.com/blang/semver/v4"
-	"github.com/google/go-github/v30/github"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/tesh254/pons/internal/constants"
+	"github.com/tesh254/pons/internal/scraper"
 	"github.com/tesh254/pons/internal/version"
 )
 
@@ -32,7 +30,7 @@ var rootCmd = &cobra.Command{
 			return nil
 		}
 
-		if cmd.Flags().NFlag() == 0 && len(args) == 0 {
+		if cmd.Flags().NFlag() == 0 && len(args) == 0 && !viper.GetBool("no-banner") {
 			fmt.Print(constants.ASCII)
 			fmt.Println(constants.CurrentOSWithVersion())
 			fmt.Printf("\n%s\n", constants.GetReleaseInfo())
@@ -129,8 +127,21 @@ func init() {
 		os.Exit(1)
 	}
 
-	rootCmd.PersistentFlags().String("db", filepath.Join(home, ".pons_data", "pons.db"), "Path to the database file")
-	rootCmd.PersistentFlags().String("worker-url", "https://vectors.madebyknnls.com", "Cloudflare worker URL for embeddings")
+	rootCmd.PersistentFlags().String("db", filepath.Join(home, ".pons_data", "pons.db"), "Path to the database file (or set PONS_DB)")
+	rootCmd.PersistentFlags().String("worker-url", "https://vectors.madebyknnls.com", "Cloudflare worker URL for embeddings (or set PONS_WORKER_URL)")
+	rootCmd.PersistentFlags().String("embedding-provider", "worker", `Embeddings provider: "worker" for the Cloudflare worker, or "openai" for any OpenAI-compatible API (or set PONS_EMBEDDING_PROVIDER)`)
+	rootCmd.PersistentFlags().String("embedding-model", "", "Model requested from the openai provider, e.g. text-embedding-3-small or nomic-embed-text (or set PONS_EMBEDDING_MODEL)")
+	rootCmd.PersistentFlags().String("embedding-base-url", "", "Base URL of the openai provider's API, e.g. http://localhost:11434/v1 for Ollama (default https://api.openai.com/v1; or set PONS_EMBEDDING_BASE_URL)")
+	rootCmd.PersistentFlags().Duration("embedding-timeout", 30*time.Second, "Timeout for each embeddings request; 0 waits forever (or set PONS_EMBEDDING_TIMEOUT)")
+	rootCmd.PersistentFlags().Int("embedding-retries", 3, "How many times an embeddings request is retried after a network error, 429 or 5xx (or set PONS_EMBEDDING_RETRIES)")
+	rootCmd.PersistentFlags().Int("embedding-max-chars", 0, "Longest text in characters sent for embedding; 0 means no limit (or set PONS_EMBEDDING_MAX_CHARS)")
+	rootCmd.PersistentFlags().String("embedding-overflow", "error", `What to do with text longer than --embedding-max-chars: "error" fails with a clear error, "truncate" cuts it and logs a warning (or set PONS_EMBEDDING_OVERFLOW)`)
+	rootCmd.PersistentFlags().String("user-agent", scraper.DefaultUserAgent, "User-Agent sent when scraping, requesting embeddings and checking for releases (or set PONS_USER_AGENT)")
+	rootCmd.PersistentFlags().String("proxy", "", "Proxy URL to scrape through, e.g. http://proxy:3128; HTTP_PROXY and HTTPS_PROXY are honored when unset (or set PONS_PROXY)")
+	rootCmd.PersistentFlags().Bool("proxy-embeddings", false, "Also send embeddings requests through --proxy (or set PONS_PROXY_EMBEDDINGS)")
+	rootCmd.PersistentFlags().Bool("no-banner", false, "Skip the ASCII banner and the release check (or set PONS_NO_BANNER)")
+	rootCmd.PersistentFlags().Duration("update-check-max-age", 24*time.Hour, "How long a release check result is reused before asking GitHub again (or set PONS_UPDATE_CHECK_MAX_AGE)")
+	rootCmd.PersistentFlags().String("embedding-template", "", `Go template for the text sent for embedding, with .Title, .Context and .Content ("default" prepends title and context; empty embeds content only)`)
 
 	// Version command flags
 	versionCmd.Flags().Bool("json", false, "Output version information in JSON format")
@@ -141,6 +152,19 @@ func init() {
 
 	viper.BindPFlag("db", rootCmd.PersistentFlags().Lookup("db"))
 	viper.BindPFlag("worker-url", rootCmd.PersistentFlags().Lookup("worker-url"))
+	viper.BindPFlag("embedding-template", rootCmd.PersistentFlags().Lookup("embedding-template"))
+	viper.BindPFlag("embedding-provider", rootCmd.PersistentFlags().Lookup("embedding-provider"))
+	viper.BindPFlag("embedding-model", rootCmd.PersistentFlags().Lookup("embedding-model"))
+	viper.BindPFlag("embedding-base-url", rootCmd.PersistentFlags().Lookup("embedding-base-url"))
+	viper.BindPFlag("embedding-timeout", rootCmd.PersistentFlags().Lookup("embedding-timeout"))
+	viper.BindPFlag("embedding-retries", rootCmd.PersistentFlags().Lookup("embedding-retries"))
+	viper.BindPFlag("embedding-max-chars", rootCmd.PersistentFlags().Lookup("embedding-max-chars"))
+	viper.BindPFlag("embedding-overflow", rootCmd.PersistentFlags().Lookup("embedding-overflow"))
+	viper.BindPFlag("user-agent", rootCmd.PersistentFlags().Lookup("user-agent"))
+	viper.BindPFlag("proxy", rootCmd.PersistentFlags().Lookup("proxy"))
+	viper.BindPFlag("proxy-embeddings", rootCmd.PersistentFlags().Lookup("proxy-embeddings"))
+	viper.BindPFlag("no-banner", rootCmd.PersistentFlags().Lookup("no-banner"))
+	viper.BindPFlag("update-check-max-age", rootCmd.PersistentFlags().Lookup("update-check-max-age"))
 }
 
 func initConfig() {
@@ -175,32 +199,44 @@ func initConfig() {
 		}
 	}
 
+	// Every setting can also come from a PONS_-prefixed environment variable,
+	// e.g. PONS_DB or PONS_WORKER_URL. Viper resolves them as
+	// flag > environment > config file > default.
+	viper.SetEnvPrefix("pons")
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
 	viper.AutomaticEnv()
 
 	if err := viper.ReadInConfig(); err == nil {
 		// fmt.Println("Using config file:", viper.ConfigFileUsed())
 	}
 
-	checkVersion()
+	// Skip the release check when scripted or when asked to stay quiet
+	if !viper.GetBool("no-banner") && stdoutIsTerminal() {
+		checkVersion()
+	}
 }
 
-func checkVersion() {
-	client := github.NewClient(nil)
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	release, _, err := client.Repositories.GetLatestRelease(ctx, "tesh254", "pons")
+// stdoutIsTerminal reports whether stdout is attached to a terminal.
+func stdoutIsTerminal() bool {
+	info, err := os.Stdout.Stat()
 	if err != nil {
-		return
+		return false
 	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
 
-	latestVersion, err := semver.Parse(release.GetTagName()[1:])
-	if err != nil {
+// checkVersion prints an upgrade notice when the cached release check shows a newer
+// release. When the cache is missing or older than update-check-max-age, GitHub is asked
+// in the background so startup never waits on the network; the notice then shows on a
+// later run.
+func checkVersion() {
+	latestVersion, ok := cachedReleaseVersion()
+	if !ok {
+		go latestReleaseVersion(true)
 		return
 	}
 
-	currentVersionStr := version.GetVersion()
-	currentVersion, err := semver.Parse(currentVersionStr[1:])
+	currentVersion, err := currentReleaseVersion()
 	if err != nil {
 		return
 	}
@@ -211,17 +247,7 @@ func checkVersion() {
 
 	fmt.Printf("%sA new version of pons is available: v%s%s\n", constants.ColorGreen, latestVersion, constants.ColorReset)
 
-	exe, err := os.Executable()
-	if err != nil {
-		return
-	}
-
-	var updateInstruction string
-	if strings.Contains(exe, "brew") {
-		updateInstruction = "To update, run: brew upgrade pons"
-	} else {
-		updateInstruction = "To update, run: curl -sSL https://raw.githubusercontent.com/tesh254/pons/main/install.sh | sh"
-	}
+	updateInstruction := "To update, run: pons update"
 
 	border := strings.Repeat("─", len(updateInstruction)+4)
 	fmt.Println(constants.ColorGreen + "┌" + border + "┐" + constants.ColorReset)
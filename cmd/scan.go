@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tesh254/pons/internal/scraper"
+)
+
+// scanDescriptionLength is how many characters of a page's description the scan table shows.
+const scanDescriptionLength = 80
+
+// scannedPage is a page found by pons scan.
+type scannedPage struct {
+	Path        string `json:"path"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Depth       int    `json:"depth"`
+}
+
+var scanCmd = &cobra.Command{
+	Use:   "scan [url]",
+	Short: "Lists the pages of a site with their titles and descriptions, without storing anything",
+	Long: `Crawl a site the same way pons add does and print each page's path, title and
+description. Pages are not converted, embedded or stored, which makes scan a cheap way to
+preview a site and tune crawl options before indexing it.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		rootURL := args[0]
+		if !strings.HasPrefix(rootURL, "http://") && !strings.HasPrefix(rootURL, "https://") {
+			log.Fatalf("scan needs an http:// or https:// URL, got %s", rootURL)
+		}
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+		maxPages, _ := cmd.Flags().GetInt("max-pages")
+		maxDepth, _ := cmd.Flags().GetInt("max-depth")
+		requestDelay, _ := cmd.Flags().GetDuration("request-delay")
+		maxConcurrent, _ := cmd.Flags().GetInt("max-concurrent")
+		restrictPath, _ := cmd.Flags().GetBool("restrict-path")
+		includePatterns, _ := cmd.Flags().GetStringArray("include")
+		excludePatterns, _ := cmd.Flags().GetStringArray("exclude")
+		if maxDepth < 0 {
+			log.Fatalf("--max-depth must not be negative, got %d", maxDepth)
+		}
+		if requestDelay < 0 {
+			log.Fatalf("--request-delay must not be negative, got %s", requestDelay)
+		}
+		if maxConcurrent < 1 {
+			log.Fatalf("--max-concurrent must be at least 1, got %d", maxConcurrent)
+		}
+
+		config := scraper.DefaultConfig()
+		config.Verbose = verbose
+		config.MetadataOnly = true
+		config.UserAgent = viper.GetString("user-agent")
+		config.ProxyURL = viper.GetString("proxy")
+		config.MaxPages = maxPages
+		config.MaxDepth = maxDepth
+		config.RequestDelay = requestDelay
+		config.MaxConcurrent = maxConcurrent
+		config.RestrictToPathPrefix = restrictPath
+		config.IncludePatterns = includePatterns
+		config.ExcludePatterns = excludePatterns
+		failed := 0
+		config.OnPage = func(_ string, _ int, err error) {
+			if err != nil {
+				failed++
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+		}
+
+		s, err := scraper.New(rootURL, config)
+		if err != nil {
+			log.Fatalf("Failed to initialize scraper: %v", err)
+		}
+		if err := s.GetAllPaths(); err != nil {
+			if len(s.SubPaths) == 0 {
+				log.Fatalf("Failed to scan %s: %v", rootURL, err)
+			}
+			fmt.Fprintf(os.Stderr, "Warning: failed to finish the scan, showing the pages found so far: %v\n", err)
+		}
+
+		pages := make([]scannedPage, 0, len(s.SubPaths))
+		for _, path := range s.SubPaths {
+			metadata := s.SubPathsMetadata[path]
+			pages = append(pages, scannedPage{
+				Path:        path,
+				Title:       strings.TrimSpace(metadata.Title),
+				Description: strings.TrimSpace(metadata.Description),
+				Depth:       s.SubPathsDepth[path],
+			})
+		}
+		sort.Slice(pages, func(i, j int) bool { return pages[i].Path < pages[j].Path })
+
+		if jsonOutput {
+			out, err := json.MarshalIndent(pages, "", "  ")
+			if err != nil {
+				log.Fatalf("Failed to encode pages: %v", err)
+			}
+			fmt.Println(string(out))
+			return
+		}
+		printScan(pages, failed)
+	},
+}
+
+// printScan writes the scanned pages as a table, shortening long descriptions.
+func printScan(pages []scannedPage, failed int) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.SetStyle(table.StyleLight)
+	t.AppendHeader(table.Row{"Path", "Title", "Description"})
+	for _, page := range pages {
+		description := page.Description
+		if runes := []rune(description); len(runes) > scanDescriptionLength {
+			description = string(runes[:scanDescriptionLength-1]) + "…"
+		}
+		t.AppendRow(table.Row{page.Path, page.Title, description})
+	}
+	footer := fmt.Sprintf("%d pages", len(pages))
+	if failed > 0 {
+		footer += fmt.Sprintf(", %d failed", failed)
+	}
+	t.AppendFooter(table.Row{footer, "", ""})
+	t.Render()
+}
+
+func init() {
+	rootCmd.AddCommand(scanCmd)
+	scanCmd.Flags().BoolP("verbose", "v", false, "Enable verbose output")
+	scanCmd.Flags().Bool("json", false, "Print the pages as JSON")
+	scanCmd.Flags().Int("max-depth", scraper.DefaultConfig().MaxDepth, "How many links deep to crawl from the starting URL (0 only fetches the starting page)")
+	scanCmd.Flags().Int("max-pages", 0, "Maximum number of pages to crawl (0 means unlimited)")
+	scanCmd.Flags().Duration("request-delay", scraper.DefaultConfig().RequestDelay, "Minimum time between requests to the same host")
+	scanCmd.Flags().Int("max-concurrent", scraper.DefaultConfig().MaxConcurrent, "Maximum number of pages fetched at the same time")
+	scanCmd.Flags().Bool("restrict-path", false, "Only crawl pages under the starting URL's path")
+	scanCmd.Flags().StringArray("include", nil, "Only crawl paths matching this regular expression (repeatable)")
+	scanCmd.Flags().StringArray("exclude", nil, "Never crawl paths matching this regular expression (repeatable, wins over --include)")
+}
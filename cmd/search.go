@@ -1,14 +1,17 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"log"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	"github.com/tesh254/pons/internal/api"
-	"github.com/tesh254/pons/internal/llm"
+	"github.com/tesh254/pons/internal/scraper"
 	"github.com/tesh254/pons/internal/storage"
+	"github.com/tesh254/pons/internal/vector"
 )
 
 var searchCmd = &cobra.Command{
@@ -20,14 +23,27 @@ var searchCmd = &cobra.Command{
 		numResults, _ := cmd.Flags().GetInt("num-results")
 		context, _ := cmd.Flags().GetString("context")
 		verbose, _ := cmd.Flags().GetBool("verbose")
+		language, _ := cmd.Flags().GetString("language")
+		seed, _ := cmd.Flags().GetString("seed")
+		sourceType, _ := cmd.Flags().GetString("source-type")
+		tagValues, _ := cmd.Flags().GetStringArray("tag")
+		byContext, _ := cmd.Flags().GetBool("by-context")
+		minScore, _ := cmd.Flags().GetFloat64("min-score")
+		snippetLen, _ := cmd.Flags().GetInt("snippet-len")
+		diversify, _ := cmd.Flags().GetBool("diversify")
+		groupByPage, _ := cmd.Flags().GetBool("group-by-page")
+		lambda, _ := cmd.Flags().GetFloat64("mmr-lambda")
+		metricName, _ := cmd.Flags().GetString("metric")
+		metric, err := vector.ParseMetric(metricName)
+		if err != nil {
+			log.Fatalf("Invalid --metric: %v", err)
+		}
 
 		dbPath := viper.GetString("db")
-		workerURL := viper.GetString("worker-url")
 
 		if verbose {
 			fmt.Printf("Searching for: %s\n", query)
 			fmt.Printf("Database path: %s\n", dbPath)
-			fmt.Printf("Worker URL: %s\n", workerURL)
 			fmt.Printf("Number of results: %d\n", numResults)
 			fmt.Printf("Context: %s\n", context)
 		}
@@ -40,10 +56,10 @@ var searchCmd = &cobra.Command{
 		defer st.Close()
 
 		// Initialize LLM
-		if workerURL == "" {
-			log.Fatalf("worker-url is required for search command")
+		emb, err := newEmbedder()
+		if err != nil {
+			log.Fatalf("Failed to initialize embeddings: %v", err)
 		}
-		emb := llm.NewEmbeddings(workerURL)
 
 		// Initialize API
 		ponsAPI := api.NewAPI(st, emb)
@@ -52,9 +68,21 @@ var searchCmd = &cobra.Command{
 		if verbose {
 			fmt.Println("Performing search...")
 		}
-		results, err := ponsAPI.Search(query, numResults, context) // Pass query string directly
+		filter := storage.SearchFilter{
+			Context:    context,
+			Language:   scraper.NormalizeLanguage(language),
+			SeedURL:    seed,
+			SourceType: sourceType,
+			Tags:       parseTags(tagValues),
+		}
+		var results []api.SearchResult
+		if diversify {
+			results, err = ponsAPI.SearchDiverse(query, numResults, minScore, lambda, filter, metric, groupByPage)
+		} else {
+			results, err = ponsAPI.SearchWithFilter(query, numResults, minScore, filter, metric, groupByPage)
+		}
 		if err != nil {
-			if err.Error() == "no documents found for search" { // Updated error message
+			if errors.Is(err, api.ErrNoDocuments) {
 				fmt.Println("No documents found in storage for the provided context.")
 				return
 			}
@@ -65,24 +93,66 @@ var searchCmd = &cobra.Command{
 			fmt.Println("No relevant documents found.")
 			return
 		}
+		if snippetLen != api.DefaultSnippetLength {
+			for i := range results {
+				results[i].Snippet = api.Snippet(results[i].Doc.Content, query, snippetLen)
+			}
+		}
 
 		fmt.Println("\nSearch Results:")
-		for i, result := range results {
-			fmt.Printf("%d. URL: %s (Score: %.4f)\n", i+1, result.Doc.URL, result.Score)
-			// Optionally print title/description/content snippet
-			if verbose {
-				fmt.Printf("   Title: %s\n", result.Doc.Title)
-				fmt.Printf("   Description: %s\n", result.Doc.Description)
-				// fmt.Printf("   Content Snippet: %s...\n", result.Doc.Content[:min(len(result.Doc.Content), 200)])
+		if byContext {
+			for _, group := range api.GroupByContext(results) {
+				fmt.Printf("\n[%s]\n", group.Context)
+				printSearchResults(group.Results, verbose)
 			}
+			return
 		}
+		printSearchResults(results, verbose)
 	},
 }
 
+// printSearchResults prints a numbered list of search results.
+func printSearchResults(results []api.SearchResult, verbose bool) {
+	for i, result := range results {
+		if result.MatchingChunks > 1 {
+			fmt.Printf("%d. URL: %s (Score: %.4f, %d matching chunks)\n", i+1, result.Doc.DeepLink(), result.Score, result.MatchingChunks)
+		} else {
+			fmt.Printf("%d. URL: %s (Score: %.4f)\n", i+1, result.Doc.DeepLink(), result.Score)
+		}
+		// Optionally print title/description
+		if verbose {
+			fmt.Printf("   Title: %s\n", result.Doc.Title)
+			fmt.Printf("   Description: %s\n", result.Doc.Description)
+		}
+		if result.Snippet != "" {
+			fmt.Printf("   %s\n", result.Snippet)
+		}
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(searchCmd)
 	searchCmd.Flags().IntP("num-results", "n", 3, "Number of search results to return")
 	searchCmd.Flags().StringP("context", "c", "", "Context to search within (e.g., 'shopify-admin')")
+	searchCmd.Flags().String("language", "", "Only search documents in this language (e.g., 'en'); --lang also works")
+	searchCmd.Flags().SetNormalizeFunc(languageFlagAlias)
+	searchCmd.Flags().StringArray("tag", nil, "Only search documents carrying this tag (repeatable; all must match)")
+	searchCmd.Flags().String("seed", "", "Only search documents from crawls started at this URL")
+	searchCmd.Flags().String("source-type", "", "Only search documents of this source type (web_scrape, file_read or pdf)")
+	searchCmd.Flags().Float64("min-score", 0, "Drop results whose similarity score is below this value (e.g., 0.5)")
+	searchCmd.Flags().String("metric", "cosine", "Similarity metric used to score results: cosine, dot or euclidean (euclidean scores are negative distances)")
+	searchCmd.Flags().Int("snippet-len", api.DefaultSnippetLength, "Length of the content excerpt shown for each result (0 hides it)")
+	searchCmd.Flags().Bool("diversify", false, "Re-rank results with Maximal Marginal Relevance so near-duplicates don't crowd out other matches")
+	searchCmd.Flags().Float64("mmr-lambda", api.DefaultMMRLambda, "With --diversify, weight of relevance against diversity, from 0 (most diverse) to 1 (most relevant)")
+	searchCmd.Flags().Bool("group-by-page", false, "Return only the best-matching chunk of each page, with how many of its chunks matched")
+	searchCmd.Flags().Bool("by-context", false, "Group results by the context they came from")
 	searchCmd.Flags().BoolP("verbose", "v", false, "Enable verbose output")
 }
 
+// languageFlagAlias lets --lang stand in for --language.
+func languageFlagAlias(f *pflag.FlagSet, name string) pflag.NormalizedName {
+	if name == "lang" {
+		name = "language"
+	}
+	return pflag.NormalizedName(name)
+}
@@ -2,12 +2,13 @@ package cmd
 
 import (
 	"log"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/tesh254/pons/internal/api"
 	"github.com/tesh254/pons/internal/core"
-	"github.com/tesh254/pons/internal/llm"
+	"github.com/tesh254/pons/internal/index"
 	"github.com/tesh254/pons/internal/storage"
 )
 
@@ -16,16 +17,15 @@ var startCmd = &cobra.Command{
 	Short: "Starts the MCP server",
 	Run: func(cmd *cobra.Command, args []string) {
 		dbPath := viper.GetString("db")
-		workerURL := "https://vectors.madebyknnls.com"
 		httpAddress := viper.GetString("http-address")
 		transport := viper.GetString("transport")
 
-		if transport == "stdio" {
-			httpAddress = ""
+		logFormat := viper.GetString("log-format")
+		if logFormat != "text" && logFormat != "json" {
+			log.Fatalf("Invalid --log-format %q (use text or json)", logFormat)
 		}
 
 		log.Printf("DB Path: %s", dbPath)
-		log.Printf("Worker URL: %s", workerURL)
 
 		log.Println("Initializing storage...")
 		// Initialize storage
@@ -38,16 +38,52 @@ var startCmd = &cobra.Command{
 
 		log.Println("Initializing LLM...")
 		// Initialize LLM
-		emb := llm.NewEmbeddings(workerURL)
-		log.Println("LLM initialized.")
+		emb, err := newEmbedder()
+		if err != nil {
+			log.Fatalf("Failed to initialize embeddings: %v", err)
+		}
+		log.Printf("LLM initialized: %s", emb.Model())
 
 		// Initialize API
 		ponsAPI := api.NewAPI(st, emb)
 
+		// Mixed embedding dimensions (usually from a model change) silently degrade search
+		problems, err := ponsAPI.CheckDimensions()
+		if err != nil {
+			log.Printf("Failed to check embedding dimensions: %v", err)
+		}
+		for _, problem := range problems {
+			log.Printf("\033[33mWARNING: %s. Re-add the affected documents with the current embedding model.\033[0m", problem)
+		}
+		if len(problems) > 0 && viper.GetBool("strict") {
+			log.Fatalf("Refusing to start with inconsistent embedding dimensions (--strict)")
+		}
+		if viper.GetBool("ann-index") {
+			log.Println("Building in-memory ANN index in the background...")
+			indexConfig := index.DefaultConfig()
+			if efSearch := viper.GetInt("ann-ef-search"); efSearch > 0 {
+				indexConfig.EfSearch = efSearch
+			}
+			ponsAPI.EnableIndex(indexConfig)
+		}
+		ponsAPI.EnableSearchCache(viper.GetInt("search-cache-size"), viper.GetDuration("search-cache-ttl"))
+
 		// Start MCP server
 		log.Println("Starting MCP server...")
-		mcpServer := &core.Core{}
-		if err := mcpServer.StartServer(ponsAPI, httpAddress); err != nil {
+		mcpServer := &core.Core{
+			EmbeddingTemplate: viper.GetString("embedding-template"),
+			UserAgent:         viper.GetString("user-agent"),
+			ProxyURL:          viper.GetString("proxy"),
+			AuthToken:         viper.GetString("auth-token"),
+			RateLimit:         viper.GetFloat64("rate-limit"),
+			RateBurst:         viper.GetInt("rate-burst"),
+			LogFormat:         logFormat,
+			OnChangeWebhook:   viper.GetString("on-change-webhook"),
+		}
+		if transport == "http" && mcpServer.AuthToken == "" {
+			log.Printf("\033[33mWARNING: the HTTP server accepts unauthenticated requests; set --auth-token to require a bearer token.\033[0m")
+		}
+		if err := mcpServer.StartServer(ponsAPI, transport, httpAddress); err != nil {
 			log.Fatalf("Server error: %v", err)
 		}
 	},
@@ -55,8 +91,28 @@ var startCmd = &cobra.Command{
 
 func init() {
 	rootCmd.AddCommand(startCmd)
-	startCmd.Flags().String("http-address", "localhost:9014", "HTTP address to listen on")
-	startCmd.Flags().String("transport", "stdio", "Transport type (stdio or http)")
+	startCmd.Flags().String("http-address", "localhost:9014", "HTTP address to listen on with --transport http")
+	startCmd.Flags().String("transport", "stdio", "Transport type (stdio or http); --http-address is ignored for stdio")
 	viper.BindPFlag("http-address", startCmd.Flags().Lookup("http-address"))
 	viper.BindPFlag("transport", startCmd.Flags().Lookup("transport"))
+	startCmd.Flags().String("auth-token", "", "Bearer token HTTP clients must send in the Authorization header (or set PONS_AUTH_TOKEN)")
+	viper.BindPFlag("auth-token", startCmd.Flags().Lookup("auth-token"))
+	startCmd.Flags().Float64("rate-limit", 0, "Requests per second each client IP may make over HTTP (0 disables rate limiting)")
+	startCmd.Flags().Int("rate-burst", 10, "Requests a client IP may make in a burst above --rate-limit")
+	viper.BindPFlag("rate-limit", startCmd.Flags().Lookup("rate-limit"))
+	viper.BindPFlag("rate-burst", startCmd.Flags().Lookup("rate-burst"))
+	startCmd.Flags().String("log-format", "text", "Format of HTTP request logs: text or json (one JSON object per request)")
+	viper.BindPFlag("log-format", startCmd.Flags().Lookup("log-format"))
+	startCmd.Flags().String("on-change-webhook", "", "URL to POST a JSON notification to whenever a document's content changes")
+	viper.BindPFlag("on-change-webhook", startCmd.Flags().Lookup("on-change-webhook"))
+	startCmd.Flags().Bool("strict", false, "Refuse to start when stored embeddings have inconsistent dimensions")
+	viper.BindPFlag("strict", startCmd.Flags().Lookup("strict"))
+	startCmd.Flags().Bool("ann-index", false, "Serve searches from an in-memory approximate nearest neighbor index")
+	startCmd.Flags().Int("ann-ef-search", 0, "Candidate list size for ANN index searches (higher is more accurate, 0 uses the default)")
+	viper.BindPFlag("ann-index", startCmd.Flags().Lookup("ann-index"))
+	viper.BindPFlag("ann-ef-search", startCmd.Flags().Lookup("ann-ef-search"))
+	startCmd.Flags().Int("search-cache-size", 0, "Number of repeated searches to cache (0 disables the cache)")
+	startCmd.Flags().Duration("search-cache-ttl", 5*time.Minute, "How long cached search results stay valid")
+	viper.BindPFlag("search-cache-size", startCmd.Flags().Lookup("search-cache-size"))
+	viper.BindPFlag("search-cache-ttl", startCmd.Flags().Lookup("search-cache-ttl"))
 }
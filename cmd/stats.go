@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tesh254/pons/internal/storage"
+)
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Shows document counts, content size and embedding dimension per context",
+	Run: func(cmd *cobra.Command, args []string) {
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+
+		st, err := storage.NewStorage(viper.GetString("db"))
+		if err != nil {
+			log.Fatalf("Failed to initialize storage: %v", err)
+		}
+		defer st.Close()
+
+		stats, err := st.Stats()
+		if err != nil {
+			log.Fatalf("Failed to collect stats: %v", err)
+		}
+
+		if jsonOutput {
+			out, err := json.MarshalIndent(stats, "", "  ")
+			if err != nil {
+				log.Fatalf("Failed to encode stats: %v", err)
+			}
+			fmt.Println(string(out))
+			return
+		}
+
+		if stats.Documents == 0 {
+			fmt.Println("No documents found.")
+			return
+		}
+
+		t := table.NewWriter()
+		t.SetOutputMirror(os.Stdout)
+		t.SetStyle(table.StyleLight)
+		t.AppendHeader(table.Row{"Context", "Documents", "Content Bytes", "Dimension"})
+		for _, c := range stats.Contexts {
+			t.AppendRow(table.Row{c.Context, c.Documents, c.ContentBytes, c.Dimension})
+		}
+		t.AppendFooter(table.Row{"Total", stats.Documents, stats.ContentBytes, stats.Dimension})
+		t.Render()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+	statsCmd.Flags().Bool("json", false, "Print the stats as JSON")
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/blang/semver/v4"
+	"github.com/google/go-github/v30/github"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/tesh254/pons/internal/constants"
+	"github.com/tesh254/pons/internal/version"
+)
+
+const installScriptURL = "https://raw.githubusercontent.com/tesh254/pons/main/install.sh"
+
+var updateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Updates pons to the latest release",
+	Long: `Checks GitHub for the latest pons release and installs it, using Homebrew when pons
+was installed with brew and the install script otherwise.
+
+With --check, only reports whether an update is available.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		checkOnly, _ := cmd.Flags().GetBool("check")
+
+		latestVersion, err := latestReleaseVersion(true)
+		if err != nil {
+			log.Fatalf("Failed to check for updates: %v", err)
+		}
+
+		currentVersion, err := currentReleaseVersion()
+		if err != nil {
+			log.Fatalf("Cannot compare against this build (%s): %v", version.GetVersion(), err)
+		}
+
+		if latestVersion.LE(currentVersion) {
+			fmt.Printf("pons v%s is up to date.\n", currentVersion)
+			return
+		}
+
+		fmt.Printf("%sA new version of pons is available: v%s (current v%s)%s\n", constants.ColorGreen, latestVersion, currentVersion, constants.ColorReset)
+		if checkOnly {
+			fmt.Println("Run `pons update` to install it.")
+			return
+		}
+
+		command := updateCommand()
+		fmt.Printf("Running: %s\n", command)
+		update := exec.Command("sh", "-c", command)
+		update.Stdin = os.Stdin
+		update.Stdout = os.Stdout
+		update.Stderr = os.Stderr
+		if err := update.Run(); err != nil {
+			log.Fatalf("Update failed: %v", err)
+		}
+		fmt.Printf("%sUpdated pons to v%s.%s\n", constants.ColorGreen, latestVersion, constants.ColorReset)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(updateCmd)
+	updateCmd.Flags().Bool("check", false, "Check for a newer release now without installing it")
+}
+
+// releaseCheck is the cached result of the last release lookup.
+type releaseCheck struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Latest    string    `json:"latest"`
+}
+
+// releaseCheckPath returns where the last release lookup is cached.
+func releaseCheckPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".pons", "update-check.json"), nil
+}
+
+// cachedReleaseVersion returns the latest release recorded by the last lookup, if that
+// lookup is younger than update-check-max-age.
+func cachedReleaseVersion() (semver.Version, bool) {
+	cachePath, err := releaseCheckPath()
+	if err != nil {
+		return semver.Version{}, false
+	}
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		return semver.Version{}, false
+	}
+	var cached releaseCheck
+	if json.Unmarshal(data, &cached) != nil || time.Since(cached.CheckedAt) >= viper.GetDuration("update-check-max-age") {
+		return semver.Version{}, false
+	}
+	latestVersion, err := semver.Parse(cached.Latest)
+	return latestVersion, err == nil
+}
+
+// latestReleaseVersion returns the newest published release and records it for
+// cachedReleaseVersion. A cached result is reused unless force is set.
+func latestReleaseVersion(force bool) (semver.Version, error) {
+	if !force {
+		if latestVersion, ok := cachedReleaseVersion(); ok {
+			return latestVersion, nil
+		}
+	}
+
+	client := github.NewClient(nil)
+	if userAgent := viper.GetString("user-agent"); userAgent != "" {
+		client.UserAgent = userAgent
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	release, _, err := client.Repositories.GetLatestRelease(ctx, "tesh254", "pons")
+	if err != nil {
+		return semver.Version{}, err
+	}
+
+	latestVersion, err := semver.Parse(strings.TrimPrefix(release.GetTagName(), "v"))
+	if err != nil {
+		return semver.Version{}, err
+	}
+
+	// Write through a temporary file so a process exiting mid-write never leaves a
+	// truncated cache behind
+	if cachePath, err := releaseCheckPath(); err == nil {
+		if data, err := json.Marshal(releaseCheck{CheckedAt: time.Now(), Latest: latestVersion.String()}); err == nil {
+			tmp := cachePath + ".tmp"
+			if os.WriteFile(tmp, data, 0600) == nil {
+				_ = os.Rename(tmp, cachePath)
+			}
+		}
+	}
+	return latestVersion, nil
+}
+
+// currentReleaseVersion parses the version of the running build.
+func currentReleaseVersion() (semver.Version, error) {
+	return semver.Parse(strings.TrimPrefix(version.GetVersion(), "v"))
+}
+
+// updateCommand returns the shell command that upgrades this installation.
+func updateCommand() string {
+	if exe, err := os.Executable(); err == nil && strings.Contains(exe, "brew") {
+		return "brew upgrade pons"
+	}
+	return "curl -sSL " + installScriptURL + " | sh"
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCachedReleaseVersion(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	setConfig(t, map[string]string{"update-check-max-age": "1h"})
+
+	if _, ok := cachedReleaseVersion(); ok {
+		t.Error("found a release without a cache file")
+	}
+
+	os.MkdirAll(filepath.Join(home, ".pons"), 0755)
+	writeCheck := func(check releaseCheck) {
+		data, _ := json.Marshal(check)
+		if err := os.WriteFile(filepath.Join(home, ".pons", "update-check.json"), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeCheck(releaseCheck{CheckedAt: time.Now().Add(-time.Minute), Latest: "1.2.3"})
+	if latest, ok := cachedReleaseVersion(); !ok || latest.String() != "1.2.3" {
+		t.Errorf("fresh cache = %v, %v; want 1.2.3", latest, ok)
+	}
+
+	writeCheck(releaseCheck{CheckedAt: time.Now().Add(-2 * time.Hour), Latest: "1.2.3"})
+	if _, ok := cachedReleaseVersion(); ok {
+		t.Error("used a cache entry older than update-check-max-age")
+	}
+}
@@ -5,19 +5,24 @@ import (
 	"log"
 	"math"
 	"sort"
+	"strings"
 
 	"github.com/tesh254/pons/internal/llm"
 	"github.com/tesh254/pons/internal/storage"
+	"github.com/tesh254/pons/internal/vector"
 )
 
 // API provides methods to interact with the document storage.
 type API struct {
-	storage *storage.Storage
-	llm     *llm.Embeddings
+	storage  *storage.Storage
+	llm      llm.Embedder
+	ann      annState
+	cache    *searchCache
+	onChange []func(*storage.Document)
 }
 
 // NewAPI creates a new API instance.
-func NewAPI(storage *storage.Storage, llm *llm.Embeddings) *API {
+func NewAPI(storage *storage.Storage, llm llm.Embedder) *API {
 	return &API{
 		storage: storage,
 		llm:     llm,
@@ -25,7 +30,7 @@ func NewAPI(storage *storage.Storage, llm *llm.Embeddings) *API {
 }
 
 // Llm returns the llm instance.
-func (a *API) Llm() *llm.Embeddings {
+func (a *API) Llm() llm.Embedder {
 	return a.llm
 }
 
@@ -41,7 +46,7 @@ func (a *API) UpsertDocument(baseURL, url, title, description, content, checksum
 		Context:     context,
 		SourceType:  sourceType,
 	}
-	return a.storage.UpsertDocument(doc)
+	return a.UpsertDirect(doc)
 }
 
 // GetDocument retrieves a document by URL.
@@ -51,51 +56,257 @@ func (a *API) GetDocument(url string, context string) (*storage.Document, error)
 
 // DeleteDocument deletes a document by URL.
 func (a *API) DeleteDocument(url, context string) error {
-	return a.storage.DeleteDocumentsByPrefix(url, context)
+	if err := a.storage.DeleteDocumentsByPrefix(url, context); err != nil {
+		return err
+	}
+	a.invalidateCache()
+	a.rebuildIndex()
+	return nil
+}
+
+// DeleteStaleChunks removes the stored rows of url, or of its url#chunkN chunks, that are not in keep.
+// It is used after re-chunking a page so chunks from an older, longer version don't linger.
+func (a *API) DeleteStaleChunks(url, context string, keep []string) error {
+	deleted, err := a.storage.DeleteStaleChunks(url, context, keep)
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		a.invalidateCache()
+		a.rebuildIndex()
+	}
+	return nil
+}
+
+// DeleteBySeed deletes every document stored by crawls started from seed, optionally
+// filtered by context, and returns how many were deleted.
+func (a *API) DeleteBySeed(seed, context string) (int64, error) {
+	deleted, err := a.storage.DeleteDocumentsBySeed(seed, context)
+	if err != nil {
+		return 0, err
+	}
+	if deleted > 0 {
+		a.invalidateCache()
+		a.rebuildIndex()
+	}
+	return deleted, nil
+}
+
+// DeleteByContext deletes every document in a context and returns how many were deleted.
+func (a *API) DeleteByContext(context string) (int, error) {
+	deleted, err := a.storage.DeleteByContext(context)
+	if err != nil {
+		return 0, err
+	}
+	if deleted > 0 {
+		a.invalidateCache()
+		a.rebuildIndex()
+	}
+	return deleted, nil
+}
+
+// RenameContext moves every document in oldContext to newContext. Renaming onto a context
+// that already has documents is refused unless merge is set.
+func (a *API) RenameContext(oldContext, newContext string, merge bool) error {
+	if oldContext == "" || newContext == "" {
+		return fmt.Errorf("context names must not be empty")
+	}
+	if oldContext == newContext {
+		return fmt.Errorf("context %s is already named %s", oldContext, newContext)
+	}
+
+	count, err := a.storage.CountDocuments(storage.SearchFilter{Context: oldContext})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("no documents found in context %s", oldContext)
+	}
+
+	if !merge {
+		existing, err := a.storage.CountDocuments(storage.SearchFilter{Context: newContext})
+		if err != nil {
+			return err
+		}
+		if existing > 0 {
+			return fmt.Errorf("context %s already has %d documents; pass merge to combine them", newContext, existing)
+		}
+	}
+
+	if err := a.storage.RenameContext(oldContext, newContext); err != nil {
+		return err
+	}
+	a.invalidateCache()
+	a.rebuildIndex()
+	return nil
 }
 
 type SearchResult struct {
 	Doc   *storage.Document
 	Score float64
+	// Snippet is an excerpt of Doc.Content around the passage best matching the query,
+	// at most DefaultSnippetLength characters long
+	Snippet string
+	// MatchingChunks is how many matching chunks or sections of the page were collapsed
+	// into this result; it is 0 unless results were collapsed by URL
+	MatchingChunks int
+}
+
+// ContextGroup holds the search results that came from one context.
+type ContextGroup struct {
+	Context string
+	Results []SearchResult
+}
+
+// GroupByContext groups results by document context. Each group keeps its results
+// ordered by score, and groups are ordered by their best score.
+func GroupByContext(results []SearchResult) []ContextGroup {
+	var groups []ContextGroup
+	positions := make(map[string]int)
+	for _, result := range results {
+		pos, ok := positions[result.Doc.Context]
+		if !ok {
+			pos = len(groups)
+			positions[result.Doc.Context] = pos
+			groups = append(groups, ContextGroup{Context: result.Doc.Context})
+		}
+		groups[pos].Results = append(groups[pos].Results, result)
+	}
+
+	for _, group := range groups {
+		sort.SliceStable(group.Results, func(i, j int) bool {
+			return group.Results[i].Score > group.Results[j].Score
+		})
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Results[0].Score > groups[j].Results[0].Score
+	})
+	return groups
 }
 
 // Search finds the most similar documents to a query, up to numResults, optionally filtered by context.
-func (a *API) Search(query string, numResults int, context string) ([]SearchResult, error) {
-	queryEmbedding, err := a.llm.GenerateEmbeddings(query)
+// Documents scoring below minScore are dropped before truncating to numResults, so a query with no
+// good match returns no results rather than the least bad ones; pass 0 to keep every match.
+// Similarity is scored with metric, usually vector.Cosine. Euclidean scores are negative
+// distances, so for them a minScore of 0 keeps every match too.
+// With collapseByURL, only the best-scoring chunk of each page is returned, so a long page
+// split into many chunks can't take every slot; see SearchResult.MatchingChunks.
+// Callers pass the raw query string; the API owns generating its embedding.
+func (a *API) Search(query string, numResults int, context string, minScore float64, metric vector.Metric, collapseByURL bool) ([]SearchResult, error) {
+	return a.SearchWithFilter(query, numResults, minScore, storage.SearchFilter{Context: context}, metric, collapseByURL)
+}
+
+// SearchWithFilter is Search restricted to the documents matching filter.
+func (a *API) SearchWithFilter(query string, numResults int, minScore float64, filter storage.SearchFilter, metric vector.Metric, collapseByURL bool) ([]SearchResult, error) {
+	if collapseByURL {
+		candidates, err := a.SearchWithFilter(query, numResults*collapseCandidates, minScore, filter, metric, false)
+		if err != nil {
+			return nil, err
+		}
+		return collapseByPage(candidates, numResults), nil
+	}
+
+	if a.cache != nil {
+		if results, ok := a.cache.results.get(searchKey(query, numResults, minScore, filter, metric)); ok {
+			return append([]SearchResult(nil), results...), nil
+		}
+	}
+
+	queryEmbedding, err := a.queryEmbedding(query)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := a.search(queryEmbedding, numResults, minScore, filter, metric)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Snippet = Snippet(results[i].Doc.Content, query, DefaultSnippetLength)
+	}
+
+	if a.cache != nil {
+		a.cache.results.put(searchKey(query, numResults, minScore, filter, metric), append([]SearchResult(nil), results...))
+	}
+	return results, nil
+}
+
+// queryEmbedding embeds a search query, reusing a cached embedding when available.
+func (a *API) queryEmbedding(query string) ([]float32, error) {
+	if a.cache != nil {
+		if embedding, ok := a.cache.embeddings.get(normalizeQuery(query)); ok {
+			return embedding, nil
+		}
+	}
+
+	embedding, err := a.llm.GenerateEmbeddings(query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create embedding for query: %v", err)
 	}
 
-	docs, err := a.storage.SearchDocChunks(query, context) // Use the new storage function
+	if a.cache != nil {
+		a.cache.embeddings.put(normalizeQuery(query), embedding)
+	}
+	return embedding, nil
+}
+
+// search ranks stored documents scoring at least minScore against a query embedding.
+func (a *API) search(queryEmbedding []float32, numResults int, minScore float64, filter storage.SearchFilter, metric vector.Metric) ([]SearchResult, error) {
+	// The ANN index is built for cosine similarity
+	if metric == vector.Cosine {
+		if results, ok := a.searchIndex(queryEmbedding, numResults, minScore, filter); ok {
+			return results, nil
+		}
+	}
+	if metric == vector.Euclidean && minScore == 0 {
+		minScore = math.Inf(-1)
+	}
+
+	docs, err := a.storage.SearchDocChunks("", filter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search documents: %v", err)
 	}
 
 	if len(docs) == 0 {
-		return nil, fmt.Errorf("no documents found for search")
+		return nil, ErrNoDocuments
 	}
 
 	var results []SearchResult
 
+	// Stored embeddings have unit length once normalized, so normalizing the query once
+	// reduces cosine similarity to a dot product
+	similarityFunc := metric.Similarity
+	if metric == vector.Cosine && a.storage.EmbeddingsNormalized() {
+		queryEmbedding = vector.Normalize(queryEmbedding)
+		similarityFunc = vector.DotProduct
+	}
+
 	for _, doc := range docs {
 		if len(doc.Embeddings) == 0 {
 			log.Printf("Skipping document %s due to empty embeddings", doc.URL)
 			continue // Skip documents without embeddings
 		}
-		similarity, err := cosineSimilarity(queryEmbedding, doc.Embeddings)
+		// Stored embeddings are normalized, which cosine ignores but dot and Euclidean
+		// scores depend on, so those compare against the embedding as the model produced it
+		embedding := doc.Embeddings
+		if metric != vector.Cosine {
+			embedding = doc.RawEmbeddings()
+		}
+		similarity, err := similarityFunc(queryEmbedding, embedding)
 		if err != nil {
-			log.Printf("Error calculating cosine similarity for document %s: %v (queryEmbedding length: %d, doc.Embeddings length: %d)", doc.URL, err, len(queryEmbedding), len(doc.Embeddings))
+			log.Printf("Error calculating %s similarity for document %s: %v (queryEmbedding length: %d, doc.Embeddings length: %d)", metric, doc.URL, err, len(queryEmbedding), len(doc.Embeddings))
 			continue
 		}
 		// log.Printf("Document %s similarity: %f", doc.URL, similarity) // Commented out for less verbose logging
+		if similarity < minScore {
+			continue
+		}
 
 		results = append(results, SearchResult{Doc: doc, Score: similarity})
 	}
 
 	// Sort results by similarity in descending order
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Score > results[j].Score
-	})
+	sortResults(results)
 
 	// Return top N results
 	if len(results) > numResults {
@@ -105,42 +316,138 @@ func (a *API) Search(query string, numResults int, context string) ([]SearchResu
 	return results, nil
 }
 
-// cosineSimilarity computes the cosine similarity between two vectors.
-// This is a helper function, as the one in the llm package is a method on the Embeddings struct.
-// A standalone function here avoids circular dependencies if llm needed to use the api package.
-func cosineSimilarity(a, b []float32) (float64, error) {
-	if len(a) != len(b) {
-		return 0, fmt.Errorf("vectors must have the same length")
-	}
+// sortResults orders results by descending score, breaking ties by URL so equally
+// scored documents always come back in the same order.
+func sortResults(results []SearchResult) {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Doc.URL < results[j].Doc.URL
+	})
+}
 
-	var dotProduct, aMagnitude, bMagnitude float64
-	for i := 0; i < len(a); i++ {
-		dotProduct += float64(a[i] * b[i])
-		aMagnitude += float64(a[i] * a[i])
-		bMagnitude += float64(b[i] * b[i])
+// UpsertDirect upserts a document directly.
+func (a *API) UpsertDirect(doc *storage.Document) error {
+	if doc.EmbeddingModel == "" && a.llm != nil {
+		doc.EmbeddingModel = a.llm.Model()
 	}
+	changed := a.changedDocuments([]*storage.Document{doc})
+	if err := a.storage.UpsertDocument(doc); err != nil {
+		return err
+	}
+	a.invalidateCache()
+	a.notifyChanged(changed)
+	return nil
+}
 
-	if aMagnitude == 0 || bMagnitude == 0 {
-		return 0, nil
+// UpsertDocuments upserts documents in a single transaction.
+func (a *API) UpsertDocuments(docs []*storage.Document) error {
+	for _, doc := range docs {
+		if doc.EmbeddingModel == "" && a.llm != nil {
+			doc.EmbeddingModel = a.llm.Model()
+		}
+	}
+	changed := a.changedDocuments(docs)
+	if err := a.storage.UpsertDocuments(docs); err != nil {
+		return err
 	}
+	a.invalidateCache()
+	a.notifyChanged(changed)
+	return nil
+}
 
-	return dotProduct / (math.Sqrt(aMagnitude) * math.Sqrt(bMagnitude)), nil
+// Ping checks that the storage can be queried.
+func (a *API) Ping() error {
+	return a.storage.Ping()
 }
 
-// UpsertDirect upserts a document directly.
-func (a *API) UpsertDirect(doc *storage.Document) error {
-	return a.storage.UpsertDocument(doc)
+// Stats summarizes the stored documents per context and in total.
+func (a *API) Stats() (*storage.Stats, error) {
+	return a.storage.Stats()
 }
 
-// ListDocuments lists all documents, optionally filtered by context.
-func (a *API) ListDocuments(context string, limit int) ([]*storage.Document, error) {
+// ListDocuments lists one page of the documents that pass the filter.
+func (a *API) ListDocuments(filter storage.SearchFilter, limit, offset int) ([]*storage.Document, error) {
 	if limit <= 0 {
 		limit = 10 // Default limit
 	}
-	return a.storage.ListDocuments(context, limit)
+	if offset < 0 {
+		offset = 0
+	}
+	return a.storage.ListDocuments(filter, limit, offset)
+}
+
+// TrimDocuments drops the content and embeddings of docs, unless includeContent or
+// includeEmbeddings is set, for listings that only need the documents' metadata.
+func TrimDocuments(docs []*storage.Document, includeContent, includeEmbeddings bool) {
+	for _, doc := range docs {
+		if !includeContent {
+			doc.Content = ""
+		}
+		if !includeEmbeddings {
+			doc.Embeddings = nil
+		}
+	}
+}
+
+// CountDocuments counts the stored documents that pass the filter.
+func (a *API) CountDocuments(filter storage.SearchFilter) (int, error) {
+	return a.storage.CountDocuments(filter)
+}
+
+// ListIncomplete lists documents missing any of the optional metadata fields selected by criteria.
+func (a *API) ListIncomplete(criteria storage.IncompleteCriteria) ([]*storage.Document, error) {
+	return a.storage.ListIncomplete(criteria)
 }
 
 // GetContexts retrieves a list of unique contexts.
 func (a *API) GetContexts() ([]string, error) {
 	return a.storage.GetContexts()
 }
+
+// CheckDimensions reports data-quality problems with stored embedding dimensions:
+// contexts whose documents mix dimensions, and different contexts using different
+// dimensions (which breaks searches across all contexts). It returns one message per problem.
+func (a *API) CheckDimensions() ([]string, error) {
+	counts, err := a.storage.EmbeddingDimensions()
+	if err != nil {
+		return nil, err
+	}
+
+	byContext := make(map[string][]storage.DimensionCount)
+	var contexts []string
+	allDimensions := make(map[int]int)
+	for _, c := range counts {
+		if _, ok := byContext[c.Context]; !ok {
+			contexts = append(contexts, c.Context)
+		}
+		byContext[c.Context] = append(byContext[c.Context], c)
+		allDimensions[c.Dimension] += c.Documents
+	}
+
+	var problems []string
+	for _, context := range contexts {
+		if dims := byContext[context]; len(dims) > 1 {
+			problems = append(problems, fmt.Sprintf("context %q mixes embedding dimensions: %s", context, describeDimensions(dims)))
+		}
+	}
+	if len(allDimensions) > 1 && len(contexts) > 1 {
+		var dims []storage.DimensionCount
+		for dimension, documents := range allDimensions {
+			dims = append(dims, storage.DimensionCount{Dimension: dimension, Documents: documents})
+		}
+		sort.Slice(dims, func(i, j int) bool { return dims[i].Dimension < dims[j].Dimension })
+		problems = append(problems, fmt.Sprintf("contexts use different embedding dimensions, so searches across all contexts are unreliable: %s", describeDimensions(dims)))
+	}
+	return problems, nil
+}
+
+// describeDimensions formats dimension counts as "384 (120 documents), 768 (3 documents)".
+func describeDimensions(dims []storage.DimensionCount) string {
+	parts := make([]string, len(dims))
+	for i, d := range dims {
+		parts[i] = fmt.Sprintf("%d (%d documents)", d.Dimension, d.Documents)
+	}
+	return strings.Join(parts, ", ")
+}
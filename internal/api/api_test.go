@@ -0,0 +1,100 @@
+package api
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/tesh254/pons/internal/storage"
+	"github.com/tesh254/pons/internal/vector"
+)
+
+// fixedEmbedder embeds every query as the same vector.
+type fixedEmbedder []float32
+
+func (e fixedEmbedder) GenerateEmbeddings(string) ([]float32, error) { return e, nil }
+
+func (e fixedEmbedder) Model() string { return "fixed" }
+
+// TestSearchMetricsRankDifferently checks that dot and Euclidean scores use the embeddings
+// as the model produced them rather than their normalized form, so each metric ranks the
+// same documents in its own order.
+func TestSearchMetricsRankDifferently(t *testing.T) {
+	st, err := storage.NewStorage(filepath.Join(t.TempDir(), "pons.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	a := NewAPI(st, fixedEmbedder{1, 0})
+	docs := []*storage.Document{
+		// Long and off-axis: worst angle, largest dot product, farthest away
+		{URL: "https://example.com/long", Content: "long", Context: "c", Embeddings: []float32{6, 8}},
+		// Close to the query, slightly off-axis
+		{URL: "https://example.com/near", Content: "near", Context: "c", Embeddings: []float32{1, 0.1}},
+		// Same direction as the query, but short
+		{URL: "https://example.com/short", Content: "short", Context: "c", Embeddings: []float32{0.5, 0}},
+	}
+	if err := a.UpsertDocuments(docs); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		metric vector.Metric
+		want   []string
+	}{
+		{vector.Cosine, []string{"short", "near", "long"}},
+		{vector.Dot, []string{"long", "near", "short"}},
+		{vector.Euclidean, []string{"near", "short", "long"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.metric.String(), func(t *testing.T) {
+			results, err := a.Search("query", 3, "c", 0, tt.metric, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, result := range results {
+				got = append(got, result.Doc.Content)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("%s ranked %v, want %v", tt.metric, got, tt.want)
+			}
+		})
+	}
+}
+
+// recordingEmbedder records the queries it embeds.
+type recordingEmbedder struct {
+	queries []string
+}
+
+func (e *recordingEmbedder) GenerateEmbeddings(query string) ([]float32, error) {
+	e.queries = append(e.queries, query)
+	return []float32{1, 0}, nil
+}
+
+func (e *recordingEmbedder) Model() string { return "recording" }
+
+func TestQueryEmbeddingCacheKeepsCase(t *testing.T) {
+	st, err := storage.NewStorage(filepath.Join(t.TempDir(), "pons.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	emb := &recordingEmbedder{}
+	a := NewAPI(st, emb)
+	a.EnableSearchCache(10, time.Minute)
+	for _, query := range []string{"Go", "go", "  Go ", "go\tmodules", "go  modules"} {
+		if _, err := a.queryEmbedding(query); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Whitespace variants reuse the cached embedding; case variants don't
+	if want := []string{"Go", "go", "go\tmodules"}; !slices.Equal(emb.queries, want) {
+		t.Errorf("embedded %q, want %q", emb.queries, want)
+	}
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"container/list"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tesh254/pons/internal/storage"
+	"github.com/tesh254/pons/internal/vector"
+)
+
+// lruCache is a small concurrency-safe LRU cache whose entries expire after a TTL.
+type lruCache[V any] struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type lruEntry[V any] struct {
+	key     string
+	value   V
+	expires time.Time
+}
+
+func newLRUCache[V any](size int, ttl time.Duration) *lruCache[V] {
+	return &lruCache[V]{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// get returns the cached value for key if present and not expired.
+func (c *lruCache[V]) get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	el, ok := c.entries[key]
+	if !ok {
+		return zero, false
+	}
+	entry := el.Value.(*lruEntry[V])
+	if time.Now().After(entry.expires) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return zero, false
+	}
+	c.order.MoveToFront(el)
+	return entry.value, true
+}
+
+// put stores a value, evicting the least recently used entry when full.
+func (c *lruCache[V]) put(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := time.Now().Add(c.ttl)
+	if el, ok := c.entries[key]; ok {
+		entry := el.Value.(*lruEntry[V])
+		entry.value = value
+		entry.expires = expires
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry[V]{key: key, value: value, expires: expires})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry[V]).key)
+	}
+}
+
+// clear drops every entry.
+func (c *lruCache[V]) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+}
+
+// searchCache memoizes query embeddings and search results between writes.
+// Embeddings only depend on the query text, so they survive invalidation;
+// results are dropped whenever a document is written or deleted.
+type searchCache struct {
+	embeddings *lruCache[[]float32]
+	results    *lruCache[[]SearchResult]
+}
+
+// EnableSearchCache turns on caching of repeated searches. Up to size queries are
+// kept for ttl; any upsert or delete through the API invalidates cached results.
+// A size or ttl of zero leaves caching disabled.
+func (a *API) EnableSearchCache(size int, ttl time.Duration) {
+	if size <= 0 || ttl <= 0 {
+		a.cache = nil
+		return
+	}
+	a.cache = &searchCache{
+		embeddings: newLRUCache[[]float32](size, ttl),
+		results:    newLRUCache[[]SearchResult](size, ttl),
+	}
+}
+
+// normalizeQuery collapses whitespace so trivially different queries share a cache entry.
+// Case is kept: embedding models tell "Go" from "go", so the two are cached apart.
+func normalizeQuery(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// searchKey identifies a search request in the results cache.
+func searchKey(query string, numResults int, minScore float64, filter storage.SearchFilter, metric vector.Metric) string {
+	return fmt.Sprintf("%s\x00%s\x00%s\x00%s\x00%s\x00%s\x00%d\x00%g\x00%d", normalizeQuery(query), filter.Context, filter.Language, filter.SeedURL, filter.SourceType, strings.Join(filter.Tags, "\x01"), numResults, minScore, metric)
+}
+
+// invalidateCache drops cached search results after a write.
+func (a *API) invalidateCache() {
+	if a.cache != nil {
+		a.cache.results.clear()
+	}
+}
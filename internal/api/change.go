@@ -0,0 +1,44 @@
+package api
+
+import (
+	"log"
+
+	"github.com/tesh254/pons/internal/storage"
+)
+
+// OnChange registers fn to be called after a document is upserted through the API with
+// a checksum different from the one stored before, including when it is new. Upserts
+// that leave the checksum unchanged don't call it. Hooks run synchronously, in the
+// order they were registered, so register them before the API is used and keep them quick.
+func (a *API) OnChange(fn func(*storage.Document)) {
+	a.onChange = append(a.onChange, fn)
+}
+
+// changedDocuments returns the documents of docs whose checksum differs from the stored one.
+// It returns nil without querying the storage when no OnChange hook is registered.
+func (a *API) changedDocuments(docs []*storage.Document) []*storage.Document {
+	if len(a.onChange) == 0 {
+		return nil
+	}
+	var changed []*storage.Document
+	for _, doc := range docs {
+		checksum, err := a.storage.GetChecksum(doc.URL, doc.Context)
+		if err != nil {
+			log.Printf("Failed to read checksum of %s: %v", doc.URL, err)
+			continue
+		}
+		if checksum != doc.Checksum {
+			changed = append(changed, doc)
+		}
+	}
+	return changed
+}
+
+// notifyChanged calls every OnChange hook for each changed document.
+func (a *API) notifyChanged(changed []*storage.Document) {
+	for _, doc := range changed {
+		for _, fn := range a.onChange {
+			fn(doc)
+		}
+	}
+}
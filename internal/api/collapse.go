@@ -0,0 +1,29 @@
+package api
+
+import "strings"
+
+// collapseCandidates is how many candidates per requested result are fetched when
+// collapsing results by page.
+const collapseCandidates = 10
+
+// collapseByPage keeps the best-scoring result for each page, dropping the other chunks
+// and sections of the same page, and returns up to numResults of them. Each kept result's
+// MatchingChunks counts the results of its page. results must be sorted by score.
+func collapseByPage(results []SearchResult, numResults int) []SearchResult {
+	var collapsed []SearchResult
+	positions := make(map[string]int)
+	for _, result := range results {
+		page, _, _ := strings.Cut(result.Doc.URL, "#")
+		if pos, ok := positions[page]; ok {
+			collapsed[pos].MatchingChunks++
+			continue
+		}
+		positions[page] = len(collapsed)
+		result.MatchingChunks = 1
+		collapsed = append(collapsed, result)
+	}
+	if len(collapsed) > numResults {
+		collapsed = collapsed[:numResults]
+	}
+	return collapsed
+}
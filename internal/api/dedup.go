@@ -0,0 +1,52 @@
+package api
+
+import (
+	"github.com/tesh254/pons/internal/storage"
+	"github.com/tesh254/pons/internal/vector"
+)
+
+// Deduplicator drops documents whose embedding is nearly identical to that of a
+// document it kept earlier, such as pages that differ only in boilerplate.
+// Every document is compared with every kept one, so it suits a single ingest run
+// rather than a whole store.
+type Deduplicator struct {
+	threshold float64
+	kept      [][]float32
+}
+
+// NewDeduplicator creates a Deduplicator treating documents whose cosine similarity to
+// a kept document exceeds threshold as duplicates.
+func NewDeduplicator(threshold float64) *Deduplicator {
+	return &Deduplicator{threshold: threshold}
+}
+
+// Filter returns the documents of docs that are not duplicates of a document kept so
+// far, including earlier documents of docs, along with how many were dropped. Kept
+// documents are remembered for later calls. Documents without embeddings are kept.
+func (d *Deduplicator) Filter(docs []*storage.Document) ([]*storage.Document, int) {
+	kept := docs[:0:0]
+	for _, doc := range docs {
+		if d.isDuplicate(doc.Embeddings) {
+			continue
+		}
+		if len(doc.Embeddings) > 0 {
+			d.kept = append(d.kept, doc.Embeddings)
+		}
+		kept = append(kept, doc)
+	}
+	return kept, len(docs) - len(kept)
+}
+
+// isDuplicate reports whether embedding is more similar than the threshold to a kept one.
+func (d *Deduplicator) isDuplicate(embedding []float32) bool {
+	if len(embedding) == 0 {
+		return false
+	}
+	for _, kept := range d.kept {
+		similarity, err := vector.CosineSimilarity(embedding, kept)
+		if err == nil && similarity > d.threshold {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,14 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/tesh254/pons/internal/storage"
+)
+
+// ErrNoDocuments is returned by searches when no stored document matches the filter,
+// as opposed to documents matching but none scoring high enough.
+var ErrNoDocuments = errors.New("no documents found for search")
+
+// ErrNotFound is returned when a requested document is not stored.
+var ErrNotFound = storage.ErrNotFound
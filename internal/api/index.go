@@ -0,0 +1,185 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/tesh254/pons/internal/index"
+	"github.com/tesh254/pons/internal/storage"
+)
+
+// annState holds the optional in-memory ANN index that fronts the storage scan.
+type annState struct {
+	mu         sync.RWMutex
+	cfg        index.Config
+	idx        *index.HNSW
+	enabled    bool
+	ready      bool
+	building   bool
+	stale      bool
+	oversample int
+	// version is the storage data version the index was built from
+	version int64
+}
+
+// EnableIndex builds an in-memory ANN index over all stored embeddings in the
+// background. Any write committed after the build, whether made through the API or
+// by another pons process such as add or prune, is noticed on the next search and
+// triggers a rebuild. Until the index is ready, Search falls back to scanning storage.
+func (a *API) EnableIndex(cfg index.Config) {
+	a.ann.mu.Lock()
+	a.ann.cfg = cfg
+	a.ann.enabled = true
+	a.ann.oversample = 4
+	a.ann.mu.Unlock()
+
+	a.rebuildIndex()
+}
+
+// IndexReady reports whether Search is currently served by the ANN index.
+func (a *API) IndexReady() bool {
+	a.ann.mu.RLock()
+	defer a.ann.mu.RUnlock()
+	return a.ann.ready
+}
+
+// rebuildIndex starts a background rebuild of the ANN index from storage.
+// Writes that land while a rebuild is running trigger another rebuild.
+func (a *API) rebuildIndex() {
+	a.ann.mu.Lock()
+	defer a.ann.mu.Unlock()
+
+	if !a.ann.enabled {
+		return
+	}
+	if a.ann.building {
+		a.ann.stale = true
+		return
+	}
+	a.ann.building = true
+	a.ann.ready = false
+
+	go func() {
+		for {
+			idx, version, err := a.buildIndex()
+
+			a.ann.mu.Lock()
+			if err != nil {
+				log.Printf("Failed to build ANN index, falling back to full scans: %v", err)
+				a.ann.building = false
+				a.ann.mu.Unlock()
+				return
+			}
+			if a.ann.stale {
+				a.ann.stale = false
+				a.ann.mu.Unlock()
+				continue
+			}
+			a.ann.idx = idx
+			a.ann.version = version
+			a.ann.ready = true
+			a.ann.building = false
+			a.ann.mu.Unlock()
+			return
+		}
+	}()
+}
+
+// RebuildIndex synchronously rebuilds the ANN index from the stored embeddings and
+// returns how many vectors it holds. The new index is only installed when the ANN
+// index is enabled; otherwise the rebuild just verifies the stored embeddings load.
+func (a *API) RebuildIndex() (int, error) {
+	idx, version, err := a.buildIndex()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build ANN index: %v", err)
+	}
+
+	a.ann.mu.Lock()
+	if a.ann.enabled && !a.ann.building {
+		a.ann.idx = idx
+		a.ann.version = version
+		a.ann.ready = true
+	}
+	a.ann.mu.Unlock()
+
+	a.invalidateCache()
+	return idx.Len(), nil
+}
+
+// buildIndex loads every stored embedding into a fresh HNSW graph and returns it with
+// the storage data version read before loading, so later writes show up as a newer version.
+func (a *API) buildIndex() (*index.HNSW, int64, error) {
+	version, err := a.storage.DataVersion()
+	if err != nil {
+		return nil, 0, err
+	}
+	docs, err := a.storage.ListAllDocuments("")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	a.ann.mu.RLock()
+	idx := index.New(a.ann.cfg)
+	a.ann.mu.RUnlock()
+
+	for _, doc := range docs {
+		if len(doc.Embeddings) == 0 {
+			continue
+		}
+		if err := idx.Add(doc.URL, doc.Embeddings); err != nil {
+			log.Printf("Skipping document %s in ANN index: %v", doc.URL, err)
+		}
+	}
+	return idx, version, nil
+}
+
+// searchIndex answers a search from the ANN index. It reports false when the
+// index is not ready or could not produce enough results for the filter, in
+// which case the caller should scan storage instead.
+func (a *API) searchIndex(queryEmbedding []float32, numResults int, minScore float64, filter storage.SearchFilter) ([]SearchResult, bool) {
+	a.ann.mu.RLock()
+	if !a.ann.ready {
+		a.ann.mu.RUnlock()
+		return nil, false
+	}
+	idx, built := a.ann.idx, a.ann.version
+	k := numResults * a.ann.oversample
+	a.ann.mu.RUnlock()
+
+	// Any commit since the build, including writes made through this API, may have
+	// come from another process the index never heard of
+	if version, err := a.storage.DataVersion(); err != nil || version != built {
+		a.rebuildIndex()
+		return nil, false
+	}
+
+	hits := idx.Search(queryEmbedding, k)
+
+	var results []SearchResult
+	belowMinScore := false
+	for _, hit := range hits {
+		if hit.Score < minScore {
+			belowMinScore = true
+			continue
+		}
+		doc, err := a.storage.GetDocument(hit.ID, filter.Context)
+		if err != nil || !filter.Matches(doc) {
+			continue // Filtered out or deleted outside the API
+		}
+		results = append(results, SearchResult{Doc: doc, Score: hit.Score})
+	}
+
+	// A full page of hits that still doesn't fill the request means the
+	// filter discarded too much; let the exhaustive scan answer instead.
+	// Once hits fall below minScore, more hits wouldn't help.
+	if !belowMinScore && (len(results) == 0 || (len(results) < numResults && len(hits) == k)) {
+		return nil, false
+	}
+
+	sortResults(results)
+	if len(results) > numResults {
+		results = results[:numResults]
+	}
+	return results, true
+}
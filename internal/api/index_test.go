@@ -0,0 +1,128 @@
+package api
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tesh254/pons/internal/index"
+	"github.com/tesh254/pons/internal/storage"
+	"github.com/tesh254/pons/internal/vector"
+)
+
+// waitForIndex waits until the ANN index of a is ready.
+func waitForIndex(t *testing.T, a *API) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !a.IndexReady() {
+		if time.Now().After(deadline) {
+			t.Fatal("ANN index did not become ready")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// indexHas reports whether the ANN index of a returns url for query.
+func indexHas(a *API, query []float32, url string) bool {
+	results, ok := a.searchIndex(query, 10, 0, storage.SearchFilter{})
+	if !ok {
+		return false
+	}
+	for _, result := range results {
+		if result.Doc.URL == url {
+			return true
+		}
+	}
+	return false
+}
+
+// TestIndexPicksUpOutsideWrites checks that documents written and deleted by another
+// process, simulated by a second Storage on the same database, reach the ANN index.
+func TestIndexPicksUpOutsideWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pons.db")
+	st, err := storage.NewStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	outside, err := storage.NewStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer outside.Close()
+
+	query := []float32{1, 0}
+	if err := st.UpsertDocument(&storage.Document{URL: "https://example.com/old", Content: "old", Context: "c", Embeddings: []float32{1, 0.1}}); err != nil {
+		t.Fatal(err)
+	}
+	a := NewAPI(st, fixedEmbedder(query))
+	a.EnableIndex(index.DefaultConfig())
+	waitForIndex(t, a)
+	if !indexHas(a, query, "https://example.com/old") {
+		t.Fatal("index built without the stored document")
+	}
+
+	if err := outside.UpsertDocument(&storage.Document{URL: "https://example.com/new", Content: "new", Context: "c", Embeddings: []float32{1, 0}}); err != nil {
+		t.Fatal(err)
+	}
+	// The first search after the write notices it and falls back to a scan while the index is rebuilt
+	if _, ok := a.searchIndex(query, 10, 0, storage.SearchFilter{}); ok {
+		t.Error("index served a search after an outside write")
+	}
+	results, err := a.search(query, 10, 0, storage.SearchFilter{}, vector.Cosine)
+	if err != nil || len(results) != 2 {
+		t.Errorf("search during the rebuild = %d results, %v; want both documents", len(results), err)
+	}
+	waitForIndex(t, a)
+	if !indexHas(a, query, "https://example.com/new") {
+		t.Error("rebuilt index is missing the document written outside the API")
+	}
+
+	if err := outside.DeleteDocumentsByPrefix("https://example.com/old", "c"); err != nil {
+		t.Fatal(err)
+	}
+	a.searchIndex(query, 10, 0, storage.SearchFilter{})
+	waitForIndex(t, a)
+	if n := a.ann.idx.Len(); n != 1 {
+		t.Errorf("rebuilt index holds %d vectors after an outside delete, want 1", n)
+	}
+}
+
+// TestIndexShortfallFallsBackToScan checks that when the filter discards every hit the
+// index returns, the search is answered by scanning storage instead.
+func TestIndexShortfallFallsBackToScan(t *testing.T) {
+	st, err := storage.NewStorage(filepath.Join(t.TempDir(), "pons.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	// Twenty close matches in one context, and a single poor match in another
+	for i := 0; i < 20; i++ {
+		doc := &storage.Document{URL: fmt.Sprintf("https://example.com/a%d", i), Content: "a", Context: "a", Embeddings: []float32{1, float32(i) / 100}}
+		if err := st.UpsertDocument(doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := st.UpsertDocument(&storage.Document{URL: "https://example.com/b", Content: "b", Context: "b", Embeddings: []float32{-1, 1}}); err != nil {
+		t.Fatal(err)
+	}
+
+	query := []float32{1, 0}
+	a := NewAPI(st, fixedEmbedder(query))
+	a.EnableIndex(index.DefaultConfig())
+	waitForIndex(t, a)
+
+	filter := storage.SearchFilter{Context: "b"}
+	if _, ok := a.searchIndex(query, 2, -1, filter); ok {
+		t.Error("index answered a search whose filter discarded every hit")
+	}
+	results, err := a.search(query, 2, -1, filter, vector.Cosine)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Doc.URL != "https://example.com/b" {
+		t.Errorf("search = %v, want the only document in context b", results)
+	}
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"github.com/tesh254/pons/internal/storage"
+	"github.com/tesh254/pons/internal/vector"
+)
+
+// DefaultMMRLambda weighs relevance against diversity in SearchDiverse: 1 ranks purely by
+// relevance, 0 purely by dissimilarity to the results already picked.
+const DefaultMMRLambda = 0.5
+
+// mmrCandidates is how many candidates per requested result SearchDiverse re-ranks.
+const mmrCandidates = 5
+
+// SearchDiverse is SearchWithFilter with Maximal Marginal Relevance re-ranking: it fetches a
+// larger pool of candidates and greedily picks the one that best balances similarity to the
+// query against similarity to the results already picked, so near-duplicates of the same
+// page or section don't crowd out other relevant documents. lambda is clamped to [0, 1].
+// Candidates are scored against the query with metric; their similarity to each other is
+// always cosine. With collapseByURL, candidates are collapsed by page before re-ranking.
+func (a *API) SearchDiverse(query string, numResults int, minScore, lambda float64, filter storage.SearchFilter, metric vector.Metric, collapseByURL bool) ([]SearchResult, error) {
+	candidates, err := a.SearchWithFilter(query, numResults*mmrCandidates, minScore, filter, metric, collapseByURL)
+	if err != nil {
+		return nil, err
+	}
+	return mmr(candidates, numResults, min(max(lambda, 0), 1)), nil
+}
+
+// mmr selects up to numResults of candidates by Maximal Marginal Relevance, in the order they
+// were picked.
+func mmr(candidates []SearchResult, numResults int, lambda float64) []SearchResult {
+	if len(candidates) <= 1 || numResults <= 0 {
+		return candidates
+	}
+
+	remaining := append([]SearchResult(nil), candidates...)
+	// maxSimilarity[i] is remaining[i]'s highest similarity to a selected result
+	maxSimilarity := make([]float64, len(remaining))
+	var selected []SearchResult
+	for len(selected) < numResults && len(remaining) > 0 {
+		best, bestValue := 0, 0.0
+		for i, candidate := range remaining {
+			value := lambda*candidate.Score - (1-lambda)*maxSimilarity[i]
+			if i == 0 || value > bestValue {
+				best, bestValue = i, value
+			}
+		}
+
+		picked := remaining[best]
+		selected = append(selected, picked)
+		remaining = append(remaining[:best], remaining[best+1:]...)
+		maxSimilarity = append(maxSimilarity[:best], maxSimilarity[best+1:]...)
+
+		for i, candidate := range remaining {
+			similarity, err := vector.CosineSimilarity(picked.Doc.Embeddings, candidate.Doc.Embeddings)
+			if err != nil {
+				continue
+			}
+			if len(selected) == 1 || similarity > maxSimilarity[i] {
+				maxSimilarity[i] = similarity
+			}
+		}
+	}
+	return selected
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/tesh254/pons/internal/storage"
+	"github.com/tesh254/pons/internal/vector"
+)
+
+// SearchByEmbedding finds the documents most similar to an embedding the caller already
+// has, up to numResults, optionally filtered by context. It is Search without the step
+// of embedding a query, so vec must come from the same model as the stored embeddings.
+// Each result's snippet is the start of its content, as there is no query to center it on.
+func (a *API) SearchByEmbedding(vec []float32, numResults int, context string) ([]SearchResult, error) {
+	if len(vec) == 0 {
+		return nil, fmt.Errorf("embedding must not be empty")
+	}
+	results, err := a.search(vec, numResults, 0, storage.SearchFilter{Context: context}, vector.Cosine)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Snippet = Snippet(results[i].Doc.Content, "", DefaultSnippetLength)
+	}
+	return results, nil
+}
+
+// SimilarTo finds up to numResults documents similar to the stored document at url,
+// searching with its embedding. The document itself is left out of the results.
+// When context is empty, the document is looked up and similar ones are searched for in
+// every context.
+func (a *API) SimilarTo(url, context string, numResults int) ([]SearchResult, error) {
+	doc, err := a.storage.GetDocument(url, context)
+	if err != nil {
+		return nil, err
+	}
+	if len(doc.Embeddings) == 0 {
+		return nil, fmt.Errorf("document %s has no embeddings", url)
+	}
+
+	// Ask for one extra result, since the document is normally its own best match
+	results, err := a.SearchByEmbedding(doc.Embeddings, numResults+1, context)
+	if err != nil {
+		return nil, err
+	}
+	similar := results[:0]
+	for _, result := range results {
+		if result.Doc.URL == doc.URL && result.Doc.Context == doc.Context {
+			continue
+		}
+		similar = append(similar, result)
+	}
+	if len(similar) > numResults {
+		similar = similar[:numResults]
+	}
+	return similar, nil
+}
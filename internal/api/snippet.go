@@ -0,0 +1,122 @@
+package api
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultSnippetLength is the length, in characters, of the snippets attached to search results.
+const DefaultSnippetLength = 300
+
+// Snippet returns an excerpt of content of at most maxLen characters, centered on the
+// sentence sharing the most words with query. Whitespace is collapsed, the excerpt is cut
+// at word boundaries, and "…" marks text left out on either side. Content that fits in
+// maxLen is returned whole; a maxLen of 0 or less returns an empty snippet.
+func Snippet(content, query string, maxLen int) string {
+	if maxLen <= 0 {
+		return ""
+	}
+
+	text, sentences := splitSentences(content)
+	if len(text) <= maxLen {
+		return string(text)
+	}
+
+	// Pick the sentence matching the most distinct query terms, preferring earlier ones
+	terms := queryTerms(query)
+	best, bestScore, bestMatch := sentences[0], 0, 0
+	for _, sentence := range sentences {
+		lower := strings.ToLower(string(text[sentence[0]:sentence[1]]))
+		score, match := 0, len(lower)
+		for _, term := range terms {
+			if i := strings.Index(lower, term); i >= 0 {
+				score++
+				match = min(match, i)
+			}
+		}
+		if score > bestScore {
+			best, bestScore = sentence, score
+			bestMatch = utf8.RuneCountInString(lower[:match])
+		}
+	}
+
+	// Center the window on the sentence, or on its first match when the sentence is too long
+	start := best[0]
+	if length := best[1] - best[0]; length < maxLen {
+		start -= (maxLen - length) / 2
+	} else if bestMatch > maxLen/3 {
+		start += bestMatch - maxLen/3
+	}
+	if start > len(text)-maxLen {
+		start = len(text) - maxLen
+	}
+	if start < 0 {
+		start = 0
+	}
+	end := start + maxLen
+
+	// Don't cut words in half
+	if start > 0 && text[start-1] != ' ' {
+		for start < end && text[start] != ' ' {
+			start++
+		}
+	}
+	if end < len(text) && text[end] != ' ' {
+		for end > start && text[end-1] != ' ' {
+			end--
+		}
+	}
+
+	snippet := strings.TrimSpace(string(text[start:end]))
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(text) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+// splitSentences collapses the whitespace in content and returns the result along with
+// the [start, end) offsets of its sentences. Sentences end at ".", "!" or "?" followed by
+// whitespace, and at line breaks.
+func splitSentences(content string) ([]rune, [][2]int) {
+	var text []rune
+	var sentences [][2]int
+	start := 0
+	space, newline := false, false
+	for _, r := range content {
+		if unicode.IsSpace(r) {
+			space = true
+			newline = newline || r == '\n'
+			continue
+		}
+		if space && len(text) > 0 {
+			if last := text[len(text)-1]; newline || last == '.' || last == '!' || last == '?' {
+				sentences = append(sentences, [2]int{start, len(text)})
+				start = len(text) + 1
+			}
+			text = append(text, ' ')
+		}
+		space, newline = false, false
+		text = append(text, r)
+	}
+	if start < len(text) || len(sentences) == 0 {
+		sentences = append(sentences, [2]int{start, len(text)})
+	}
+	return text, sentences
+}
+
+// queryTerms returns the lowercase words of query worth matching, skipping very short ones.
+func queryTerms(query string) []string {
+	var terms []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(word)) >= 3 {
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
@@ -0,0 +1,21 @@
+package core
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// authHandler rejects requests that don't carry "Authorization: Bearer <token>" with a 401.
+func authHandler(token string, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		// Compare in constant time so response timing doesn't reveal the token
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="pons"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
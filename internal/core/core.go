@@ -11,10 +11,32 @@ import (
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/tesh254/pons/internal/api"
+	"github.com/tesh254/pons/internal/llm"
+	"github.com/tesh254/pons/internal/scraper"
 	"github.com/tesh254/pons/internal/storage"
+	"github.com/tesh254/pons/internal/vector"
 )
 
 type Core struct {
+	// EmbeddingTemplate optionally augments the text embedded for upserted documents.
+	// See llm.RenderEmbeddingInput.
+	EmbeddingTemplate string
+	// UserAgent, when set, replaces the scraper's default User-Agent for learn_api crawls.
+	UserAgent string
+	// ProxyURL, when set, is the proxy learn_api crawls go through.
+	ProxyURL string
+	// AuthToken, when set, is the bearer token HTTP clients must send. Stdio is unaffected.
+	AuthToken string
+	// RateLimit is how many requests per second each client IP may make over HTTP, with
+	// bursts of up to RateBurst. A RateLimit of 0 or less disables limiting.
+	RateLimit float64
+	RateBurst int
+	// LogFormat is how HTTP requests are logged: "json" for one JSON object per request,
+	// anything else for human-readable lines.
+	LogFormat string
+	// OnChangeWebhook, when set, is a URL that receives a JSON POST with the URL, context
+	// and checksum of every document whose content changes through the server.
+	OnChangeWebhook string
 }
 
 type Content struct {
@@ -23,16 +45,31 @@ type Content struct {
 }
 
 type SearchDocChunks struct {
-	Query   string `json:"query" jsonschema:"required"`
-	Context string `json:"context,omitempty"`
+	Query    string   `json:"query" jsonschema:"required"`
+	Context  string   `json:"context,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	MinScore float64  `json:"min_score,omitempty"`
+	// SourceType is "web_scrape", "file_read" or "pdf"
+	SourceType string `json:"source_type,omitempty"`
+	// Language is a language subtag such as "en"
+	Language string `json:"language,omitempty"`
+	// Diversify re-ranks results so near-duplicates don't crowd out other matches
+	Diversify bool `json:"diversify,omitempty"`
+	// FullContent returns each document's whole content instead of a snippet
+	FullContent bool `json:"full_content,omitempty"`
+	// GroupByPage returns only the best-matching chunk of each page
+	GroupByPage bool `json:"group_by_page,omitempty"`
 }
 
 type UpsertDocumentArgs struct {
-	URL         string `json:"url" jsonschema:"required"`
-	Content     string `json:"content" jsonschema:"required"`
-	Title       string `json:"title,omitempty"`
-	Description string `json:"description,omitempty"`
-	Context     string `json:"context,omitempty"`
+	URL         string   `json:"url" jsonschema:"required"`
+	Content     string   `json:"content" jsonschema:"required"`
+	Title       string   `json:"title,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Context     string   `json:"context,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	// Language is a language subtag such as "en", detected from the content when omitted
+	Language string `json:"language,omitempty"`
 }
 
 type DeleteDocumentArgs struct {
@@ -41,9 +78,18 @@ type DeleteDocumentArgs struct {
 }
 
 type ListDocumentsArgs struct {
-	Limit   int    `json:"limit,omitempty"`
-	Offset  int    `json:"offset,omitempty"`
-	Context string `json:"context,omitempty"`
+	Limit   int      `json:"limit,omitempty"`
+	Offset  int      `json:"offset,omitempty"`
+	Context string   `json:"context,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+	// SourceType is "web_scrape", "file_read" or "pdf"
+	SourceType string `json:"source_type,omitempty"`
+	// Language is a language subtag such as "en"
+	Language string `json:"language,omitempty"`
+	// IncludeContent returns each document's content, which is left out by default
+	IncludeContent bool `json:"include_content,omitempty"`
+	// IncludeEmbeddings returns each document's raw embedding vector
+	IncludeEmbeddings bool `json:"include_embeddings,omitempty"`
 }
 
 type GetDocumentArgs struct {
@@ -51,9 +97,18 @@ type GetDocumentArgs struct {
 	Context string `json:"context,omitempty"`
 }
 
-type LearnApiArgs struct {
-	Api     string `json:"api" jsonschema:"required"`
+type FindSimilarArgs struct {
+	URL     string `json:"url" jsonschema:"required"`
 	Context string `json:"context,omitempty"`
+	Limit   int    `json:"limit,omitempty"`
+	// FullContent returns each document's whole content instead of a snippet
+	FullContent bool `json:"full_content,omitempty"`
+}
+
+type LearnApiArgs struct {
+	Api      string `json:"api" jsonschema:"required"`
+	Context  string `json:"context,omitempty"`
+	MaxPages int    `json:"max_pages,omitempty"`
 }
 
 type GetContextArgs struct {
@@ -64,25 +119,50 @@ type SearchDatasetTopKArgs struct {
 	Query     string  `json:"query" jsonschema:"required"`
 	TopK      int     `json:"top_k" jsonschema:"required"`
 	Threshold float64 `json:"threshold,omitempty"`
+	// FullContent returns each document's whole content instead of a snippet
+	FullContent bool `json:"full_content,omitempty"`
 }
 
-func (c *Core) StartServer(internalAPI *api.API, httpAddress string) error {
+// StartServer serves the MCP tools over transport, either "stdio" or "http".
+// httpAddress is only used, and then required, for the http transport.
+func (c *Core) StartServer(internalAPI *api.API, transport, httpAddress string) error {
 	server := mcp.NewServer(&mcp.Implementation{Name: "Pons MCP Server", Version: "v1.0.0"}, nil)
 	c.registerTools(server, internalAPI)
-
-	if httpAddress != "" {
-		return c.ServeHTTP(server, httpAddress)
+	if c.OnChangeWebhook != "" {
+		internalAPI.OnChange(c.changeWebhook(c.OnChangeWebhook))
 	}
 
-	return c.ServeStdio(server)
+	switch transport {
+	case "stdio":
+		return c.ServeStdio(server)
+	case "http":
+		if httpAddress == "" {
+			return fmt.Errorf("the http transport needs an address to listen on")
+		}
+		return c.ServeHTTP(server, internalAPI, httpAddress)
+	default:
+		return fmt.Errorf("unknown transport %q (use stdio or http)", transport)
+	}
 }
 
-func (c *Core) ServeHTTP(server *mcp.Server, httpAddress string) error {
-	handler := mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server {
+// ServeHTTP serves the MCP server at httpAddress, along with GET /healthz and /readyz
+// probes for process managers and load balancers. The probes skip authentication and
+// rate limiting; /readyz reuses its outcome for a few seconds so probing it stays cheap.
+func (c *Core) ServeHTTP(server *mcp.Server, internalAPI *api.API, httpAddress string) error {
+	var handler http.Handler = mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server {
 		return server
 	}, nil)
+	if c.AuthToken != "" {
+		handler = authHandler(c.AuthToken, handler)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /healthz", healthHandler())
+	mux.Handle("GET /readyz", readyHandler(internalAPI))
+	mux.Handle("/", rateLimitHandler(c.RateLimit, c.RateBurst, handler))
+
 	log.Printf("Pons MCP handler listening at %s", httpAddress)
-	return http.ListenAndServe(httpAddress, loggingHandler(handler))
+	return http.ListenAndServe(httpAddress, loggingHandler(c.LogFormat, mux))
 }
 
 func (c *Core) ServeStdio(server *mcp.Server) error {
@@ -103,12 +183,42 @@ type DocumentOutput struct {
 
 // New struct to include score for search results
 type SearchOutput struct {
-	URL         string  `json:"url"`
-	Title       string  `json:"title"`
-	Description string  `json:"description"`
-	Content     string  `json:"content"`
-	Checksum    string  `json:"checksum"`
-	Score       float64 `json:"score"`
+	URL         string `json:"url"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	// Content is only returned when full content was requested
+	Content string `json:"content,omitempty"`
+	// Snippet is an excerpt of the content around the passage best matching the query
+	Snippet  string  `json:"snippet"`
+	Checksum string  `json:"checksum"`
+	Score    float64 `json:"score"`
+	// Link points at the matching section of the page when the document has an anchor
+	Link string `json:"link"`
+	// MatchingChunks is how many chunks of the page matched when results are grouped by page
+	MatchingChunks int `json:"matching_chunks,omitempty"`
+}
+
+// toSearchOutputs converts search results to the JSON shape returned by the search tools,
+// including each document's content only when fullContent is set.
+func toSearchOutputs(results []api.SearchResult, fullContent bool) []SearchOutput {
+	var searchOutputs []SearchOutput
+	for _, res := range results {
+		output := SearchOutput{
+			URL:            res.Doc.URL,
+			Title:          res.Doc.Title,
+			Description:    res.Doc.Description,
+			Snippet:        res.Snippet,
+			Checksum:       res.Doc.Checksum,
+			Score:          res.Score,
+			Link:           res.Doc.DeepLink(),
+			MatchingChunks: res.MatchingChunks,
+		}
+		if fullContent {
+			output.Content = res.Doc.Content
+		}
+		searchOutputs = append(searchOutputs, output)
+	}
+	return searchOutputs
 }
 
 func (c *Core) registerTools(server *mcp.Server, internalAPI *api.API) {
@@ -117,33 +227,53 @@ func (c *Core) registerTools(server *mcp.Server, internalAPI *api.API) {
 		Description: "Searches the knowledge base for relevant documentation and code examples based on a query string.",
 	}, func(ctx context.Context, req *mcp.CallToolRequest, args SearchDocChunks) (*mcp.CallToolResult, any, error) {
 		query := args.Query
-		results, err := internalAPI.Search(query, 3, args.Context) // Pass query string directly
+		filter := storage.SearchFilter{Context: args.Context, Language: scraper.NormalizeLanguage(args.Language), SourceType: args.SourceType, Tags: args.Tags}
+		var results []api.SearchResult
+		var err error
+		if args.Diversify {
+			results, err = internalAPI.SearchDiverse(query, 3, args.MinScore, api.DefaultMMRLambda, filter, vector.Cosine, args.GroupByPage)
+		} else {
+			results, err = internalAPI.SearchWithFilter(query, 3, args.MinScore, filter, vector.Cosine, args.GroupByPage)
+		}
 		if err != nil {
-			if err.Error() == "no documents found for search" { // Updated error message
-				return nil, nil, fmt.Errorf("no relevant documents found")
-			}
-			return nil, nil, err
+			return toolError(err)
 		}
 
 		if len(results) == 0 {
-			return nil, nil, fmt.Errorf("no relevant documents found")
+			return toolError(errNoResults)
 		}
 
-		var searchOutputs []SearchOutput
-		for _, res := range results {
-			searchOutputs = append(searchOutputs, SearchOutput{
-				URL:         res.Doc.URL,
-				Title:       res.Doc.Title,
-				Description: res.Doc.Description,
-				Content:     res.Doc.Content,
-				Checksum:    res.Doc.Checksum,
-				Score:       res.Score,
-			})
+		result, err := json.Marshal(toSearchOutputs(results, args.FullContent))
+		if err != nil {
+			return toolError(err)
 		}
 
-		result, err := json.Marshal(searchOutputs)
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{Text: string(result)},
+			},
+		}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "search_dataset_top_k",
+		Description: "Searches the whole knowledge base and returns up to top_k results, dropping any whose similarity score is below threshold.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args SearchDatasetTopKArgs) (*mcp.CallToolResult, any, error) {
+		if args.TopK <= 0 {
+			return toolError(fmt.Errorf("%w: top_k must be greater than 0", errInvalidArgument))
+		}
+		results, err := internalAPI.Search(args.Query, args.TopK, "", args.Threshold, vector.Cosine, false)
 		if err != nil {
-			return nil, nil, err
+			return toolError(err)
+		}
+
+		if len(results) == 0 {
+			return toolError(errNoResults)
+		}
+
+		result, err := json.Marshal(toSearchOutputs(results, args.FullContent))
+		if err != nil {
+			return toolError(err)
 		}
 
 		return &mcp.CallToolResult{
@@ -153,6 +283,30 @@ func (c *Core) registerTools(server *mcp.Server, internalAPI *api.API) {
 		}, nil, nil
 	})
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "find_similar_documents",
+		Description: "Finds documents similar to the stored document at `url` (\"more like this\"), up to `limit` results (default 3), leaving that document out.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args FindSimilarArgs) (*mcp.CallToolResult, any, error) {
+		limit := args.Limit
+		if limit <= 0 {
+			limit = 3
+		}
+		results, err := internalAPI.SimilarTo(args.URL, args.Context, limit)
+		if err != nil {
+			return toolError(err)
+		}
+
+		if len(results) == 0 {
+			return toolError(errNoResults)
+		}
+
+		result, err := json.Marshal(toSearchOutputs(results, args.FullContent))
+		if err != nil {
+			return toolError(err)
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: string(result)}}}, nil, nil
+	})
+
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "mandatory_initial_call",
 		Description: "✨ **MANDATORY FIRST STEP** ✨: This tool *must* be called before any other Pons tools. 🚀 To ensure the most helpful search results, always begin by calling `get_contexts` to retrieve a list of available documentation contexts. 📚 When performing a search, *strongly consider* providing a specific `context` to `search_doc_chunks` for highly relevant results. 🎯 While the `context` is optional, if the user's prompt doesn't clearly indicate a context, feel free to proceed directly with `search_doc_chunks`. You can always prompt the user for clarification after calling `get_contexts`! 🗣️",
@@ -164,15 +318,38 @@ func (c *Core) registerTools(server *mcp.Server, internalAPI *api.API) {
 		}, nil, nil
 	})
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "learn_api",
+		Description: "Crawls the documentation site at the `api` URL, then chunks, embeds and stores its pages under `context` (default: the site's host) so they can be searched. Crawls at most `max_pages` pages (default 100) and returns how many pages and chunks were indexed.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args LearnApiArgs) (*mcp.CallToolResult, any, error) {
+		summary, err := c.learnAPI(ctx, internalAPI, args)
+		if err != nil {
+			return toolError(err)
+		}
+		result, err := json.Marshal(summary)
+		if err != nil {
+			return toolError(err)
+		}
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: string(result)}}}, nil, nil
+	})
+
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "upsert_document",
 		Description: "Adds or updates a document in the knowledge base, automatically generating embeddings.",
 	}, func(ctx context.Context, req *mcp.CallToolRequest, args UpsertDocumentArgs) (*mcp.CallToolResult, any, error) {
-		embeddings, err := internalAPI.Llm().GenerateEmbeddings(args.Content)
+		embeddingInput, err := llm.RenderEmbeddingInput(c.EmbeddingTemplate, llm.EmbeddingInput{Title: args.Title, Context: args.Context, Content: args.Content})
 		if err != nil {
-			return nil, nil, err
+			return toolError(err)
+		}
+		embeddings, err := internalAPI.Llm().GenerateEmbeddings(embeddingInput)
+		if err != nil {
+			return toolError(err)
 		}
 		checksum := fmt.Sprintf("%x", sha256.Sum256([]byte(args.Content)))
+		language := scraper.NormalizeLanguage(args.Language)
+		if language == "" {
+			language = scraper.DetectLanguage(args.Content)
+		}
 		doc := &storage.Document{
 			URL:         args.URL,
 			Title:       args.Title,
@@ -181,9 +358,11 @@ func (c *Core) registerTools(server *mcp.Server, internalAPI *api.API) {
 			Checksum:    checksum,
 			Embeddings:  embeddings,
 			Context:     args.Context,
+			Language:    language,
+			Tags:        args.Tags,
 		}
 		if err := internalAPI.UpsertDirect(doc); err != nil {
-			return nil, nil, err
+			return toolError(err)
 		}
 		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "Document upserted successfully"}}}, nil, nil
 	})
@@ -194,28 +373,43 @@ func (c *Core) registerTools(server *mcp.Server, internalAPI *api.API) {
 	}, func(ctx context.Context, req *mcp.CallToolRequest, args DeleteDocumentArgs) (*mcp.CallToolResult, any, error) {
 		err := internalAPI.DeleteDocument(args.URLPrefix, args.Context)
 		if err != nil {
-			return nil, nil, err
+			return toolError(err)
 		}
 		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "Documents deleted successfully"}}}, nil, nil
 	})
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "list_documents",
-		Description: "Lists stored documents in the knowledge base with pagination, optionally filtered by context.",
+		Description: "Lists stored documents in the knowledge base with pagination, optionally filtered by context. Only metadata is returned unless `include_content` or `include_embeddings` is set.",
 	}, func(ctx context.Context, req *mcp.CallToolRequest, args ListDocumentsArgs) (*mcp.CallToolResult, any, error) {
-		docs, err := internalAPI.ListDocuments(args.Context, args.Limit)
+		filter := storage.SearchFilter{Context: args.Context, Language: scraper.NormalizeLanguage(args.Language), SourceType: args.SourceType, Tags: args.Tags}
+		total, err := internalAPI.CountDocuments(filter)
+		if err != nil {
+			return toolError(err)
+		}
+		docs, err := internalAPI.ListDocuments(filter, args.Limit, args.Offset)
+		if err != nil {
+			return toolError(err)
+		}
+		api.TrimDocuments(docs, args.IncludeContent, args.IncludeEmbeddings)
+		result, err := json.Marshal(map[string]interface{}{"documents": docs, "total": total})
 		if err != nil {
-			return nil, nil, err
+			return toolError(err)
 		}
-		start := args.Offset
-		end := start + args.Limit
-		if end > len(docs) {
-			end = len(docs)
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: string(result)}}}, nil, nil
+	})
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "get_stats",
+		Description: "Summarizes what is indexed: per context, the number of documents, total content bytes and embedding dimension, plus grand totals.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args struct{}) (*mcp.CallToolResult, any, error) {
+		stats, err := internalAPI.Stats()
+		if err != nil {
+			return toolError(err)
 		}
-		paginated := docs[start:end]
-		result, err := json.Marshal(map[string]interface{}{"documents": paginated, "total": len(docs)})
+		result, err := json.Marshal(stats)
 		if err != nil {
-			return nil, nil, err
+			return toolError(err)
 		}
 		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: string(result)}}}, nil, nil
 	})
@@ -226,11 +420,11 @@ func (c *Core) registerTools(server *mcp.Server, internalAPI *api.API) {
 	}, func(ctx context.Context, req *mcp.CallToolRequest, args GetDocumentArgs) (*mcp.CallToolResult, any, error) {
 		doc, err := internalAPI.GetDocument(args.URL, args.Context)
 		if err != nil {
-			return nil, nil, err
+			return toolError(err)
 		}
 		result, err := json.Marshal(doc)
 		if err != nil {
-			return nil, nil, err
+			return toolError(err)
 		}
 		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: string(result)}}}, nil, nil
 	})
@@ -241,12 +435,12 @@ func (c *Core) registerTools(server *mcp.Server, internalAPI *api.API) {
 	}, func(ctx context.Context, req *mcp.CallToolRequest, args GetContextArgs) (*mcp.CallToolResult, any, error) {
 		contexts, err := internalAPI.GetContexts()
 		if err != nil {
-			return nil, nil, err
+			return toolError(err)
 		}
 
 		result, err := json.Marshal(map[string]interface{}{"contexts": contexts})
 		if err != nil {
-			return nil, nil, err
+			return toolError(err)
 		}
 
 		return &mcp.CallToolResult{
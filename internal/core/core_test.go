@@ -0,0 +1,66 @@
+package core
+
+import (
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStartServerTransport(t *testing.T) {
+	// An address that is already taken makes the http transport fail as soon as it
+	// tries to listen, which shows it was the one selected
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer taken.Close()
+
+	tests := []struct {
+		name      string
+		transport string
+		address   string
+		wantErr   string
+	}{
+		{"unknown transport", "grpc", "", `unknown transport "grpc"`},
+		{"http without address", "http", "", "needs an address"},
+		{"http", "http", taken.Addr().String(), "address already in use"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Core{}
+			err := c.StartServer(newTestAPI(t, &countingEmbedder{}), tt.transport, tt.address)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("StartServer(%q, %q) = %v, want an error containing %q", tt.transport, tt.address, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStartServerStdio(t *testing.T) {
+	// The stdio transport serves until its input ends
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = stdin }()
+	w.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		c := &Core{}
+		done <- c.StartServer(newTestAPI(t, &countingEmbedder{}), "stdio", "")
+	}()
+	select {
+	case err := <-done:
+		if err != nil && strings.Contains(err.Error(), "transport") {
+			t.Errorf("stdio transport was not selected: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stdio server did not stop when its input ended")
+	}
+}
@@ -0,0 +1,66 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/tesh254/pons/internal/api"
+	"github.com/tesh254/pons/internal/llm"
+)
+
+// errNoResults is returned by the search tools when documents were searched but none
+// matched well enough.
+var errNoResults = errors.New("no relevant documents found")
+
+// errInvalidArgument wraps errors caused by a tool's arguments.
+var errInvalidArgument = errors.New("invalid argument")
+
+// Error codes reported in a failed tool call's structured content, so clients can tell
+// error conditions apart without parsing messages.
+const (
+	codeNoDocuments     = "no_documents"
+	codeNoResults       = "no_results"
+	codeNotFound        = "not_found"
+	codeInvalidArgument = "invalid_argument"
+	codeInputTooLong    = "input_too_long"
+	codeError           = "error"
+)
+
+// toolErrorOutput is the structured content of a failed tool call.
+type toolErrorOutput struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// toolError reports err as a failed tool call: the result is marked as an error and
+// carries a toolErrorOutput both as JSON text and as structured content.
+func toolError(err error) (*mcp.CallToolResult, any, error) {
+	output := toolErrorOutput{Code: errorCode(err), Message: err.Error()}
+	text, jsonErr := json.Marshal(output)
+	if jsonErr != nil {
+		return nil, nil, err
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: string(text)}},
+		IsError: true,
+	}, output, nil
+}
+
+// errorCode returns the error code reported for err.
+func errorCode(err error) string {
+	switch {
+	case errors.Is(err, api.ErrNoDocuments):
+		return codeNoDocuments
+	case errors.Is(err, errNoResults):
+		return codeNoResults
+	case errors.Is(err, api.ErrNotFound):
+		return codeNotFound
+	case errors.Is(err, errInvalidArgument):
+		return codeInvalidArgument
+	case errors.Is(err, llm.ErrInputTooLong):
+		return codeInputTooLong
+	default:
+		return codeError
+	}
+}
@@ -0,0 +1,125 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/tesh254/pons/internal/api"
+	"github.com/tesh254/pons/internal/version"
+)
+
+// readinessTimeout bounds how long /readyz waits for the embedding worker to answer.
+const readinessTimeout = 10 * time.Second
+
+// readinessTTL is how long a /readyz outcome is served before the checks run again.
+const readinessTTL = 5 * time.Second
+
+// healthStatus is the JSON body served by /healthz and /readyz.
+type healthStatus struct {
+	Status  string `json:"status"`
+	Version string `json:"version"`
+	// Storage and Embedder are "ok" or the error that made the check fail; only /readyz
+	// checks them
+	Storage  string `json:"storage,omitempty"`
+	Embedder string `json:"embedder,omitempty"`
+}
+
+// healthHandler answers liveness probes: it responds 200 as long as the server is serving.
+func healthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeHealth(w, http.StatusOK, healthStatus{Status: "ok", Version: version.GetBuildInfo().Version})
+	})
+}
+
+// readyHandler answers readiness probes: it responds 200 when the storage can be queried
+// and the embedding worker returns an embedding, and 503 otherwise. The probe is open to
+// anyone, so the outcome is reused for readinessTTL and concurrent probes share one check,
+// rather than every request costing an embedding round-trip.
+func readyHandler(internalAPI *api.API) http.Handler {
+	check := &readinessCheck{api: internalAPI}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, code := check.result()
+		writeHealth(w, code, status)
+	})
+}
+
+// readinessCheck caches the outcome of the last readiness check.
+type readinessCheck struct {
+	api *api.API
+
+	mu      sync.Mutex
+	status  healthStatus
+	code    int
+	checked time.Time
+	// running is closed once the check in flight finishes; nil when none is
+	running chan struct{}
+}
+
+// result returns the cached outcome while it is fresh, and otherwise waits for a new
+// check, starting one unless another probe already has.
+func (c *readinessCheck) result() (healthStatus, int) {
+	c.mu.Lock()
+	if !c.checked.IsZero() && time.Since(c.checked) < readinessTTL {
+		defer c.mu.Unlock()
+		return c.status, c.code
+	}
+	if c.running == nil {
+		c.running = make(chan struct{})
+		go c.run(c.running)
+	}
+	running := c.running
+	c.mu.Unlock()
+
+	<-running
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.status, c.code
+}
+
+// run checks the storage and the embedding worker, records the outcome and closes done.
+func (c *readinessCheck) run(done chan struct{}) {
+	status := healthStatus{Status: "ready", Version: version.GetBuildInfo().Version, Storage: "ok", Embedder: "ok"}
+	code := http.StatusOK
+	if err := c.api.Ping(); err != nil {
+		status.Storage = err.Error()
+		status.Status, code = "not ready", http.StatusServiceUnavailable
+	}
+	if err := checkEmbedder(c.api); err != nil {
+		status.Embedder = err.Error()
+		status.Status, code = "not ready", http.StatusServiceUnavailable
+	}
+
+	c.mu.Lock()
+	c.status, c.code, c.checked = status, code, time.Now()
+	c.running = nil
+	c.mu.Unlock()
+	close(done)
+}
+
+// checkEmbedder embeds a short text to verify the embedding worker is reachable.
+func checkEmbedder(internalAPI *api.API) error {
+	if internalAPI.Llm() == nil {
+		return fmt.Errorf("no embedder configured")
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := internalAPI.Llm().GenerateEmbeddings("ping")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(readinessTimeout):
+		return fmt.Errorf("embedding worker did not respond within %v", readinessTimeout)
+	}
+}
+
+func writeHealth(w http.ResponseWriter, code int, status healthStatus) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/tesh254/pons/internal/api"
+	"github.com/tesh254/pons/internal/storage"
+)
+
+// countingEmbedder counts the embeddings it is asked for.
+type countingEmbedder struct {
+	calls atomic.Int32
+}
+
+func (e *countingEmbedder) GenerateEmbeddings(string) ([]float32, error) {
+	e.calls.Add(1)
+	return []float32{1, 0}, nil
+}
+
+func (e *countingEmbedder) Model() string { return "counting" }
+
+func newTestAPI(t *testing.T, emb *countingEmbedder) *api.API {
+	t.Helper()
+	st, err := storage.NewStorage(filepath.Join(t.TempDir(), "pons.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(st.Close)
+	return api.NewAPI(st, emb)
+}
+
+func TestReadyzSharesOneEmbeddingCheck(t *testing.T) {
+	emb := &countingEmbedder{}
+	handler := readyHandler(newTestAPI(t, emb))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if rec.Code != http.StatusOK {
+				t.Errorf("status %d, want 200: %s", rec.Code, rec.Body)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Probes after the check finished reuse its outcome too
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if calls := emb.calls.Load(); calls != 1 {
+		t.Errorf("embedder called %d times for 21 probes, want 1", calls)
+	}
+}
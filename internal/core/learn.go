@@ -0,0 +1,177 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/tesh254/pons/internal/api"
+	"github.com/tesh254/pons/internal/llm"
+	"github.com/tesh254/pons/internal/scraper"
+	"github.com/tesh254/pons/internal/storage"
+)
+
+// defaultLearnMaxPages caps a learn_api crawl when the caller doesn't set max_pages.
+const defaultLearnMaxPages = 100
+
+// LearnSummary reports what a learn_api call indexed.
+type LearnSummary struct {
+	URL     string `json:"url"`
+	Context string `json:"context"`
+	// Pages is how many crawled pages had content stored
+	Pages int `json:"pages"`
+	// Chunks is how many documents were stored across those pages
+	Chunks int `json:"chunks"`
+	// Failed counts pages that could not be fetched, converted, embedded or stored
+	Failed int `json:"failed"`
+}
+
+// learnAPI crawls a documentation site and stores it under a context, following the same
+// steps as the add command: pages are converted to Markdown, split into overlapping chunks,
+// embedded, and stored in one transaction per page. Pages that fail are counted and skipped.
+func (c *Core) learnAPI(ctx context.Context, internalAPI *api.API, args LearnApiArgs) (*LearnSummary, error) {
+	base, err := url.Parse(args.Api)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("%w: api must be an http(s) URL of the documentation to learn, got %q", errInvalidArgument, args.Api)
+	}
+
+	summary := &LearnSummary{URL: args.Api, Context: args.Context}
+	if summary.Context == "" {
+		summary.Context = base.Host
+	}
+
+	config := scraper.DefaultConfig()
+	if c.UserAgent != "" {
+		config.UserAgent = c.UserAgent
+	}
+	config.ProxyURL = c.ProxyURL
+	config.MaxPages = args.MaxPages
+	if config.MaxPages <= 0 {
+		config.MaxPages = defaultLearnMaxPages
+	}
+	// OnPage runs on the crawl's coordinator goroutine, so counting needs no locking
+	config.OnPage = func(pageURL string, _ int, err error) {
+		if err != nil {
+			log.Printf("learn_api: %v", err)
+			summary.Failed++
+		}
+	}
+
+	s, err := scraper.New(args.Api, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize scraper: %v", err)
+	}
+	// Pages without a title or description of their own are stored with the starting page's
+	if err := s.GetContent(); err == nil {
+		s.GetMetadata()
+	}
+	if err := s.CrawlWithContext(ctx); err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		log.Printf("learn_api: crawl of %s ended early, indexing the pages crawled so far: %v", args.Api, err)
+	}
+
+	paths := make([]string, 0, len(s.SubPathsHTMLContent))
+	for path := range s.SubPathsHTMLContent {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	parser := &scraper.Parser{StripSelectors: config.StripSelectors}
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		// Prefer the page's declared canonical URL so aliases of a page are stored once
+		docURL := s.PageURL(path)
+		if canonical := s.SubPathsCanonical[path]; canonical != "" {
+			docURL = canonical
+		}
+		if seen[docURL] {
+			continue
+		}
+		seen[docURL] = true
+
+		markdown, err := parser.ToMarkdown(s.SubPathsHTMLContent[path])
+		if err != nil {
+			log.Printf("learn_api: failed to convert %s: %v", docURL, err)
+			summary.Failed++
+			continue
+		}
+		if strings.TrimSpace(markdown) == "" {
+			continue
+		}
+
+		chunks := scraper.ChunkMarkdown(markdown, config.ChunkSize, config.ChunkOverlap)
+		chunkURLs := scraper.ChunkURLs(docURL, len(chunks))
+		if err := internalAPI.DeleteStaleChunks(docURL, summary.Context, chunkURLs); err != nil {
+			log.Printf("learn_api: failed to remove outdated chunks of %s: %v", docURL, err)
+		}
+
+		docs, err := c.embedChunks(internalAPI, s, path, summary.Context, chunks, chunkURLs)
+		if err != nil {
+			log.Printf("learn_api: failed to embed %s: %v", docURL, err)
+			summary.Failed++
+			continue
+		}
+		if err := internalAPI.UpsertDocuments(docs); err != nil {
+			log.Printf("learn_api: failed to store %s: %v", docURL, err)
+			summary.Failed++
+			continue
+		}
+		summary.Pages++
+		summary.Chunks += len(docs)
+	}
+
+	return summary, nil
+}
+
+// embedChunks generates embeddings for the chunks of one crawled page and returns the
+// documents to store for them.
+func (c *Core) embedChunks(internalAPI *api.API, s *scraper.Scraper, path, contextName string, chunks, chunkURLs []string) ([]*storage.Document, error) {
+	metadata := s.PageMetadata(path)
+	inputs := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		input, err := llm.RenderEmbeddingInput(c.EmbeddingTemplate, llm.EmbeddingInput{Title: metadata.Title, Context: contextName, Content: chunk})
+		if err != nil {
+			return nil, err
+		}
+		inputs[i] = input
+	}
+	embeddings, err := llm.GenerateEmbeddingsBatch(internalAPI.Llm(), inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceType := "web_scrape"
+	if s.SubPathsPDF[path] {
+		sourceType = "pdf"
+	}
+
+	docs := make([]*storage.Document, 0, len(chunks))
+	for i, chunk := range chunks {
+		docs = append(docs, &storage.Document{
+			URL:          chunkURLs[i],
+			Title:        metadata.Title,
+			Description:  metadata.Description,
+			Content:      chunk,
+			Checksum:     fmt.Sprintf("%x", sha256.Sum256([]byte(chunk))),
+			Embeddings:   embeddings[i],
+			Context:      contextName,
+			SourceType:   sourceType,
+			Language:     s.SubPathsLanguage[path],
+			ETag:         s.SubPathsValidators[path].ETag,
+			LastModified: s.SubPathsValidators[path].LastModified,
+			Depth:        s.SubPathsDepth[path],
+			SeedURL:      s.URL,
+		})
+	}
+	return docs, nil
+}
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/tesh254/pons/internal/api"
+	"github.com/tesh254/pons/internal/storage"
+)
+
+// recordingEmbedder records the texts it embeds.
+type recordingEmbedder struct {
+	mu    sync.Mutex
+	texts []string
+}
+
+func (e *recordingEmbedder) GenerateEmbeddings(text string) ([]float32, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.texts = append(e.texts, text)
+	return []float32{1, 0}, nil
+}
+
+func (e *recordingEmbedder) Model() string { return "recording" }
+
+func TestLearnAPITitlesEachPage(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><title>API reference</title></head><body><main><p>Endpoints.</p><a href="/auth">Auth</a></main></body></html>`)
+	})
+	mux.HandleFunc("/auth", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><title>Authentication</title><meta name="description" content="Tokens and keys"></head><body><main><p>Send a bearer token.</p></main></body></html>`)
+	})
+	site := httptest.NewServer(mux)
+	defer site.Close()
+
+	st, err := storage.NewStorage(filepath.Join(t.TempDir(), "pons.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	emb := &recordingEmbedder{}
+	c := &Core{EmbeddingTemplate: "default"}
+	if _, err := c.learnAPI(context.Background(), api.NewAPI(st, emb), LearnApiArgs{Api: site.URL + "/", Context: "api"}); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := st.GetDocument(site.URL+"/auth", "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Title != "Authentication" || doc.Description != "Tokens and keys" {
+		t.Errorf("auth page stored with title %q and description %q, want its own", doc.Title, doc.Description)
+	}
+	for _, text := range emb.texts {
+		if strings.Contains(text, "Send a bearer token.") && !strings.HasPrefix(text, "Title: Authentication\n") {
+			t.Errorf("auth page embedded as %q, want its own title", text)
+		}
+	}
+}
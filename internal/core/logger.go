@@ -1,6 +1,8 @@
 package core
 
 import (
+	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"time"
@@ -25,7 +27,26 @@ func (rw *responseWriter) Write(data []byte) (int, error) {
 	return n, err
 }
 
-func loggingHandler(handler http.Handler) http.Handler {
+// requestLog is a request as logged with the "json" log format.
+type requestLog struct {
+	Time       string `json:"time"`
+	RequestID  string `json:"request_id"`
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	Status     int    `json:"status"`
+	DurationMS int64  `json:"duration_ms"`
+	Bytes      int    `json:"bytes"`
+	RemoteAddr string `json:"remote_addr"`
+	UserAgent  string `json:"user_agent"`
+}
+
+// loggingHandler logs every request handled by handler. The "json" format writes one JSON
+// object per request once it completes; any other format logs human-readable lines when
+// the request arrives and when its response is sent.
+func loggingHandler(format string, handler http.Handler) http.Handler {
+	if format == "json" {
+		return jsonLoggingHandler(handler)
+	}
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		requestID := uuid.New().String()
 		start := time.Now()
@@ -56,3 +77,31 @@ func loggingHandler(handler http.Handler) http.Handler {
 		)
 	})
 }
+
+// jsonLoggingHandler is loggingHandler for the "json" format.
+func jsonLoggingHandler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+		handler.ServeHTTP(wrapped, r)
+
+		line, err := json.Marshal(requestLog{
+			Time:       start.Format(time.RFC3339),
+			RequestID:  uuid.New().String(),
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			Status:     wrapped.statusCode,
+			DurationMS: time.Since(start).Milliseconds(),
+			Bytes:      wrapped.bodySize,
+			RemoteAddr: r.RemoteAddr,
+			UserAgent:  r.Header.Get("User-Agent"),
+		})
+		if err != nil {
+			log.Printf("[ERROR] failed to encode request log: %v", err)
+			return
+		}
+		// Bypass the logger's own timestamp prefix so each line is valid JSON
+		fmt.Fprintln(log.Writer(), string(line))
+	})
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/tesh254/pons/internal/storage"
+)
+
+// webhookTimeout bounds each change notification sent to the webhook.
+const webhookTimeout = 10 * time.Second
+
+// changeEvent is the JSON body POSTed to the change webhook.
+type changeEvent struct {
+	URL      string `json:"url"`
+	Context  string `json:"context"`
+	Checksum string `json:"checksum"`
+}
+
+// changeWebhook returns an api.OnChange hook that POSTs a changeEvent for each changed
+// document to url. Requests are sent in the background so slow receivers don't hold up
+// upserts; failures are logged and not retried.
+func (c *Core) changeWebhook(url string) func(*storage.Document) {
+	client := &http.Client{Timeout: webhookTimeout}
+	return func(doc *storage.Document) {
+		event := changeEvent{URL: doc.URL, Context: doc.Context, Checksum: doc.Checksum}
+		go func() {
+			if err := c.postChange(client, url, event); err != nil {
+				log.Printf("[ERROR] change webhook for %s: %v", event.URL, err)
+			}
+		}()
+	}
+}
+
+// postChange sends one change event to the webhook.
+func (c *Core) postChange(client *http.Client, url string, event changeEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
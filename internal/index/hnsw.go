@@ -0,0 +1,342 @@
+// Package index provides an in-memory approximate nearest neighbor index over embeddings.
+//
+// The index is a Hierarchical Navigable Small World (HNSW) graph. It is meant to sit in
+// front of the SQLite store as a cache: the database stays the source of truth and the
+// index can always be rebuilt from it.
+package index
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/tesh254/pons/internal/vector"
+)
+
+// Config holds tuning options for the HNSW graph.
+type Config struct {
+	// M is the number of neighbors kept per node on the upper layers (layer 0 keeps 2*M)
+	M int
+	// EfConstruction is the candidate list size used while inserting
+	EfConstruction int
+	// EfSearch is the minimum candidate list size used while searching
+	EfSearch int
+}
+
+// DefaultConfig returns a configuration that works well for a few hundred thousand vectors.
+func DefaultConfig() Config {
+	return Config{
+		M:              16,
+		EfConstruction: 200,
+		EfSearch:       64,
+	}
+}
+
+// Result is a single search hit.
+type Result struct {
+	// ID is the identifier the vector was added with
+	ID string
+	// Score is the cosine similarity between the query and the vector
+	Score float64
+}
+
+type node struct {
+	id        string
+	vec       []float32
+	neighbors [][]int
+	deleted   bool
+}
+
+// HNSW is a concurrency-safe HNSW index keyed by string IDs.
+type HNSW struct {
+	mu        sync.RWMutex
+	cfg       Config
+	nodes     []*node
+	ids       map[string]int
+	entry     int
+	maxLevel  int
+	dim       int
+	levelMult float64
+	rng       *rand.Rand
+}
+
+// New creates an empty index with the given configuration.
+func New(cfg Config) *HNSW {
+	defaults := DefaultConfig()
+	if cfg.M < 2 {
+		cfg.M = defaults.M
+	}
+	if cfg.EfConstruction <= 0 {
+		cfg.EfConstruction = defaults.EfConstruction
+	}
+	if cfg.EfSearch <= 0 {
+		cfg.EfSearch = defaults.EfSearch
+	}
+	return &HNSW{
+		cfg:       cfg,
+		ids:       make(map[string]int),
+		entry:     -1,
+		levelMult: 1 / math.Log(float64(cfg.M)),
+		rng:       rand.New(rand.NewSource(1)),
+	}
+}
+
+// Len returns the number of live vectors in the index.
+func (h *HNSW) Len() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.ids)
+}
+
+// Add inserts a vector, replacing any previous vector with the same ID.
+// All vectors must share the dimension of the first one added. The replaced
+// vector is handled like a removed one; see Remove.
+func (h *HNSW) Add(id string, vec []float32) error {
+	if len(vec) == 0 {
+		return fmt.Errorf("empty vector for %s", id)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.dim == 0 {
+		h.dim = len(vec)
+	}
+	if len(vec) != h.dim {
+		return fmt.Errorf("vector for %s has dimension %d, index has %d", id, len(vec), h.dim)
+	}
+
+	if old, ok := h.ids[id]; ok {
+		h.nodes[old].deleted = true
+	}
+	h.insert(id, vector.Normalize(vec))
+	h.compactIfSparse()
+	return nil
+}
+
+// insert links a new node for a normalized vector into the graph.
+func (h *HNSW) insert(id string, vec []float32) {
+	level := int(math.Floor(-math.Log(1-h.rng.Float64()) * h.levelMult))
+	n := &node{id: id, vec: vec, neighbors: make([][]int, level+1)}
+	idx := len(h.nodes)
+	h.nodes = append(h.nodes, n)
+	h.ids[id] = idx
+
+	if h.entry == -1 {
+		h.entry = idx
+		h.maxLevel = level
+		return
+	}
+
+	ep := h.entry
+	for l := h.maxLevel; l > level; l-- {
+		ep = h.searchLayer(n.vec, ep, 1, l)[0].idx
+	}
+
+	for l := min(level, h.maxLevel); l >= 0; l-- {
+		candidates := h.searchLayer(n.vec, ep, h.cfg.EfConstruction, l)
+		neighbors := h.closest(candidates, h.maxNeighbors(l))
+		n.neighbors[l] = neighbors
+		for _, nb := range neighbors {
+			h.link(nb, idx, l)
+		}
+		ep = candidates[0].idx
+	}
+
+	if level > h.maxLevel {
+		h.entry = idx
+		h.maxLevel = level
+	}
+}
+
+// Remove marks a vector as deleted. Deleted vectors are still used for graph
+// navigation but are never returned from Search. Once deleted nodes outnumber the
+// live ones, the graph is rebuilt from the live vectors to reclaim them.
+func (h *HNSW) Remove(id string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if idx, ok := h.ids[id]; ok {
+		h.nodes[idx].deleted = true
+		delete(h.ids, id)
+		h.compactIfSparse()
+	}
+}
+
+// compactIfSparse rebuilds the graph from the live vectors when deleted nodes
+// outnumber them, so replacing and removing vectors doesn't grow the index without
+// bound. A compaction only follows about as many deletions as there are live
+// vectors, so its cost is spread over the writes that made it necessary.
+func (h *HNSW) compactIfSparse() {
+	live := len(h.ids)
+	if len(h.nodes)-live <= live {
+		return
+	}
+
+	nodes := h.nodes
+	h.nodes = make([]*node, 0, live)
+	h.ids = make(map[string]int, live)
+	h.entry, h.maxLevel = -1, 0
+	for _, n := range nodes {
+		if !n.deleted {
+			h.insert(n.id, n.vec)
+		}
+	}
+}
+
+// Search returns up to k vectors most similar to query, best first.
+func (h *HNSW) Search(query []float32, k int) []Result {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if h.entry == -1 || len(query) != h.dim || k <= 0 {
+		return nil
+	}
+
+	q := vector.Normalize(query)
+	ep := h.entry
+	for l := h.maxLevel; l > 0; l-- {
+		ep = h.searchLayer(q, ep, 1, l)[0].idx
+	}
+
+	ef := h.cfg.EfSearch
+	if k > ef {
+		ef = k
+	}
+
+	var results []Result
+	for _, c := range h.searchLayer(q, ep, ef, 0) {
+		n := h.nodes[c.idx]
+		if n.deleted {
+			continue
+		}
+		results = append(results, Result{ID: n.id, Score: 1 - c.dist})
+		if len(results) == k {
+			break
+		}
+	}
+	return results
+}
+
+// maxNeighbors returns the neighbor cap for a layer.
+func (h *HNSW) maxNeighbors(layer int) int {
+	if layer == 0 {
+		return 2 * h.cfg.M
+	}
+	return h.cfg.M
+}
+
+// link adds a directed edge from -> to on a layer, pruning from's neighbors to the closest ones.
+func (h *HNSW) link(from, to, layer int) {
+	n := h.nodes[from]
+	n.neighbors[layer] = append(n.neighbors[layer], to)
+	if len(n.neighbors[layer]) <= h.maxNeighbors(layer) {
+		return
+	}
+
+	candidates := make([]candidate, 0, len(n.neighbors[layer]))
+	for _, nb := range n.neighbors[layer] {
+		candidates = append(candidates, candidate{idx: nb, dist: distance(n.vec, h.nodes[nb].vec)})
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].dist < candidates[j].dist })
+	n.neighbors[layer] = h.closest(candidates, h.maxNeighbors(layer))
+}
+
+// closest returns the indexes of the first m candidates, which must be sorted by distance.
+func (h *HNSW) closest(candidates []candidate, m int) []int {
+	if len(candidates) > m {
+		candidates = candidates[:m]
+	}
+	out := make([]int, len(candidates))
+	for i, c := range candidates {
+		out[i] = c.idx
+	}
+	return out
+}
+
+// searchLayer runs a best-first search on one layer and returns up to ef candidates sorted by distance.
+func (h *HNSW) searchLayer(q []float32, ep, ef, layer int) []candidate {
+	visited := map[int]bool{ep: true}
+	start := candidate{idx: ep, dist: distance(q, h.nodes[ep].vec)}
+
+	toVisit := &minHeap{start}
+	found := &maxHeap{start}
+
+	for toVisit.Len() > 0 {
+		c := heap.Pop(toVisit).(candidate)
+		if c.dist > (*found)[0].dist && found.Len() >= ef {
+			break
+		}
+
+		n := h.nodes[c.idx]
+		if layer >= len(n.neighbors) {
+			continue
+		}
+		for _, nb := range n.neighbors[layer] {
+			if visited[nb] {
+				continue
+			}
+			visited[nb] = true
+
+			d := distance(q, h.nodes[nb].vec)
+			if found.Len() < ef || d < (*found)[0].dist {
+				heap.Push(toVisit, candidate{idx: nb, dist: d})
+				heap.Push(found, candidate{idx: nb, dist: d})
+				if found.Len() > ef {
+					heap.Pop(found)
+				}
+			}
+		}
+	}
+
+	out := make([]candidate, found.Len())
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = heap.Pop(found).(candidate)
+	}
+	return out
+}
+
+// distance is the cosine distance between two normalized vectors.
+func distance(a, b []float32) float64 {
+	var dot float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return 1 - dot
+}
+
+type candidate struct {
+	idx  int
+	dist float64
+}
+
+// minHeap pops the closest candidate first.
+type minHeap []candidate
+
+func (h minHeap) Len() int            { return len(h) }
+func (h minHeap) Less(i, j int) bool  { return h[i].dist < h[j].dist }
+func (h minHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *minHeap) Push(x interface{}) { *h = append(*h, x.(candidate)) }
+func (h *minHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// maxHeap pops the farthest candidate first.
+type maxHeap []candidate
+
+func (h maxHeap) Len() int            { return len(h) }
+func (h maxHeap) Less(i, j int) bool  { return h[i].dist > h[j].dist }
+func (h maxHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *maxHeap) Push(x interface{}) { *h = append(*h, x.(candidate)) }
+func (h *maxHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
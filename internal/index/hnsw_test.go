@@ -0,0 +1,168 @@
+package index
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/tesh254/pons/internal/vector"
+)
+
+// randomVectors returns n random vectors of dimension dim.
+func randomVectors(rng *rand.Rand, n, dim int) [][]float32 {
+	vectors := make([][]float32, n)
+	for i := range vectors {
+		v := make([]float32, dim)
+		for j := range v {
+			v[j] = float32(rng.NormFloat64())
+		}
+		vectors[i] = v
+	}
+	return vectors
+}
+
+// bruteForce returns the IDs of the k vectors most similar to query by cosine similarity.
+func bruteForce(vectors [][]float32, query []float32, k int) []string {
+	ids := make([]int, len(vectors))
+	scores := make([]float64, len(vectors))
+	for i, v := range vectors {
+		ids[i] = i
+		scores[i], _ = vector.CosineSimilarity(query, v)
+	}
+	sort.Slice(ids, func(a, b int) bool { return scores[ids[a]] > scores[ids[b]] })
+	out := make([]string, k)
+	for i := range out {
+		out[i] = fmt.Sprint(ids[i])
+	}
+	return out
+}
+
+func TestSearchRecall(t *testing.T) {
+	const n, dim, queries, k = 1000, 32, 50, 10
+	rng := rand.New(rand.NewSource(42))
+	vectors := randomVectors(rng, n, dim)
+
+	h := New(DefaultConfig())
+	for i, v := range vectors {
+		if err := h.Add(fmt.Sprint(i), v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	found := 0
+	for _, query := range randomVectors(rng, queries, dim) {
+		want := make(map[string]bool)
+		for _, id := range bruteForce(vectors, query, k) {
+			want[id] = true
+		}
+		for _, hit := range h.Search(query, k) {
+			if want[hit.ID] {
+				found++
+			}
+		}
+	}
+	if recall := float64(found) / (queries * k); recall < 0.9 {
+		t.Errorf("recall@%d is %.2f, want at least 0.9", k, recall)
+	}
+}
+
+func TestAddReplacesID(t *testing.T) {
+	h := New(DefaultConfig())
+	h.Add("a", []float32{1, 0})
+	h.Add("b", []float32{0.7, 0.7})
+	if err := h.Add("a", []float32{0, 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := h.Len(); n != 2 {
+		t.Errorf("Len is %d after replacing a vector, want 2", n)
+	}
+	hits := h.Search([]float32{1, 0}, 10)
+	seen := 0
+	for _, hit := range hits {
+		if hit.ID == "a" {
+			seen++
+			if hit.Score > 0.01 {
+				t.Errorf("a scored %.2f against its old vector, want its new one to score about 0", hit.Score)
+			}
+		}
+	}
+	if seen != 1 {
+		t.Errorf("a returned %d times in %v, want once", seen, hits)
+	}
+	if hits := h.Search([]float32{0, 1}, 1); len(hits) != 1 || hits[0].ID != "a" {
+		t.Errorf("search for the new vector = %v, want a", hits)
+	}
+}
+
+func TestRemovedIsNeverReturned(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	vectors := randomVectors(rng, 300, 16)
+	h := New(DefaultConfig())
+	for i, v := range vectors {
+		h.Add(fmt.Sprint(i), v)
+	}
+	removed := make(map[string]bool)
+	for i := 0; i < len(vectors); i += 3 {
+		h.Remove(fmt.Sprint(i))
+		removed[fmt.Sprint(i)] = true
+	}
+
+	for i, v := range vectors {
+		for _, hit := range h.Search(v, 20) {
+			if removed[hit.ID] {
+				t.Fatalf("search for vector %d returned removed vector %s", i, hit.ID)
+			}
+		}
+	}
+	if n, want := h.Len(), len(vectors)-len(removed); n != want {
+		t.Errorf("Len is %d, want %d", n, want)
+	}
+}
+
+func TestCompactsDeletedNodes(t *testing.T) {
+	h := New(DefaultConfig())
+	vectors := randomVectors(rand.New(rand.NewSource(3)), 50, 8)
+	for round := 0; round < 10; round++ {
+		for i, v := range vectors {
+			h.Add(fmt.Sprint(i), v)
+		}
+	}
+	if nodes := len(h.nodes); nodes > 2*len(vectors) {
+		t.Errorf("graph holds %d nodes for %d live vectors after repeated upserts", nodes, len(vectors))
+	}
+	for i, v := range vectors {
+		if hits := h.Search(v, 1); len(hits) != 1 || hits[0].ID != fmt.Sprint(i) {
+			t.Fatalf("search for vector %d after compaction = %v", i, hits)
+		}
+	}
+
+	for i := range vectors {
+		h.Remove(fmt.Sprint(i))
+	}
+	if len(h.nodes) != 0 || h.Search(vectors[0], 1) != nil {
+		t.Errorf("graph holds %d nodes after every vector was removed", len(h.nodes))
+	}
+}
+
+func TestDimensionMismatch(t *testing.T) {
+	h := New(DefaultConfig())
+	if err := h.Add("empty", nil); err == nil {
+		t.Error("Add accepted an empty vector")
+	}
+	if err := h.Add("a", []float32{1, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	err := h.Add("b", []float32{1, 0})
+	if err == nil || !strings.Contains(err.Error(), "dimension 2, index has 3") {
+		t.Errorf("Add of a 2-dimensional vector = %v, want a dimension error", err)
+	}
+	if hits := h.Search([]float32{1, 0}, 5); hits != nil {
+		t.Errorf("Search with the wrong dimension = %v, want nothing", hits)
+	}
+	if n := h.Len(); n != 1 {
+		t.Errorf("Len is %d, want 1", n)
+	}
+}
@@ -0,0 +1,48 @@
+package llm
+
+// Embedder turns text into an embedding vector.
+type Embedder interface {
+	// GenerateEmbeddings returns the embedding of content.
+	GenerateEmbeddings(content string) ([]float32, error)
+	// Model identifies the embeddings this embedder produces, so stored embeddings
+	// can be matched to the model that generated them.
+	Model() string
+}
+
+// BatchEmbedder is an Embedder that can embed several texts in one request.
+type BatchEmbedder interface {
+	Embedder
+	// GenerateEmbeddingsBatch returns the embeddings of texts, in input order.
+	GenerateEmbeddingsBatch(texts []string) ([][]float32, error)
+}
+
+// GenerateEmbeddingsBatch embeds texts with a single request when e supports batching,
+// and with one request per text otherwise. Embeddings are returned in input order.
+func GenerateEmbeddingsBatch(e Embedder, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	if batcher, ok := e.(BatchEmbedder); ok {
+		return batcher.GenerateEmbeddingsBatch(texts)
+	}
+	return generateEach(e, texts)
+}
+
+// generateEach embeds texts one request at a time.
+func generateEach(e Embedder, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	for i, text := range texts {
+		embedding, err := e.GenerateEmbeddings(text)
+		if err != nil {
+			return nil, err
+		}
+		embeddings[i] = embedding
+	}
+	return embeddings, nil
+}
+
+// Both providers implement BatchEmbedder.
+var (
+	_ BatchEmbedder = (*Embeddings)(nil)
+	_ BatchEmbedder = (*OpenAIEmbeddings)(nil)
+)
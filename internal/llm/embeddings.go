@@ -3,25 +3,46 @@ package llm
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"math"
 	"net/http"
+	"sync/atomic"
+
+	"github.com/tesh254/pons/internal/vector"
 )
 
+// Embeddings generates embeddings with the pons Cloudflare Worker.
 type Embeddings struct {
 	client *http.Client
 	url    string
+	config EmbeddingsConfig
+	// noBatch is set once the worker has rejected the shape of a batch request, so later batches
+	// go straight to one request per text
+	noBatch atomic.Bool
 }
 
-// NewEmbeddings creates a new Embeddings instance with the Cloudflare Worker URL.
+// NewEmbeddings creates a new Embeddings instance with the Cloudflare Worker URL,
+// using DefaultEmbeddingsConfig.
 func NewEmbeddings(workerURL string) *Embeddings {
+	return NewEmbeddingsWithConfig(workerURL, DefaultEmbeddingsConfig())
+}
+
+// NewEmbeddingsWithConfig creates a new Embeddings instance with the Cloudflare Worker URL
+// and the given request timeout and retry policy.
+func NewEmbeddingsWithConfig(workerURL string, config EmbeddingsConfig) *Embeddings {
 	return &Embeddings{
-		client: &http.Client{},
+		client: newHTTPClient(config),
 		url:    workerURL,
+		config: config,
 	}
 }
 
+// Model identifies the embeddings this instance produces, so stored embeddings can be
+// matched to the worker that generated them.
+func (e *Embeddings) Model() string {
+	return e.url
+}
+
 // embeddingResponse matches the Cloudflare Worker’s JSON response structure.
 type embeddingResponse struct {
 	Data    [][]float32 `json:"data"`
@@ -29,77 +50,109 @@ type embeddingResponse struct {
 	Pooling string      `json:"pooling"`
 	Usage   struct {
 		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`	
+		CompletionTokens int `json:"completion_tokens"`
 		TotalTokens      int `json:"total_tokens"`
 	} `json:"usage"`
 }
 
+// statusError is returned when the worker answers with a status other than 200 OK.
+type statusError struct {
+	code int
+	body string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d, body: %s", e.code, e.body)
+}
+
 // GenerateEmbeddings sends text to the Cloudflare Worker and returns embeddings.
 func (e *Embeddings) GenerateEmbeddings(content string) ([]float32, error) {
-	// Prepare JSON payload
-	payload := map[string]string{"text": content}
-	body, err := json.Marshal(payload)
+	content, err := limitInput(e.config, content)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal payload: %v", err)
+		return nil, err
 	}
-
-	// Make HTTP POST request to Cloudflare Worker
-	req, err := http.NewRequest("POST", e.url, bytes.NewBuffer(body))
+	result, err := e.post(map[string]string{"text": content})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
+		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := e.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %v", err)
+	if len(result.Data) == 0 || len(result.Data[0]) == 0 {
+		return nil, fmt.Errorf("empty embedding returned")
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(bodyBytes))
+	return result.Data[0], nil
+}
+
+// GenerateEmbeddingsBatch embeds all texts in one request and returns their embeddings
+// in input order. If the worker rejects the batch as malformed or too large, or answers
+// with the wrong number of embeddings, each text is embedded with its own request
+// instead, for this and every later batch. Other errors are returned as is.
+func (e *Embeddings) GenerateEmbeddingsBatch(texts []string) ([][]float32, error) {
+	if len(texts) == 1 || e.noBatch.Load() {
+		return generateEach(e, texts)
 	}
 
-	// Parse response
-	var result embeddingResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
+	texts, err := limitInputs(e.config, texts)
+	if err != nil {
+		return nil, err
+	}
+	result, err := e.post(map[string][]string{"text": texts})
+	if err != nil {
+		var status *statusError
+		if !errors.As(err, &status) || !batchRejected(status.code) {
+			return nil, err
+		}
+	} else if len(result.Data) == len(texts) {
+		return result.Data, nil
 	}
 
-	// fmt.Println(result) // Remove this line, it was for debugging
+	e.noBatch.Store(true)
+	return generateEach(e, texts)
+}
 
-	if len(result.Data) == 0 || len(result.Data[0]) == 0 {
-		return nil, fmt.Errorf("empty embedding returned")
+// batchRejected reports whether a response status means the worker doesn't accept a
+// list of texts, as opposed to a failure that would hit single requests just the same.
+func batchRejected(code int) bool {
+	switch code {
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity:
+		return true
 	}
-
-	return result.Data[0], nil
+	return false
 }
 
-// CosineSimilarity computes the cosine similarity between two vectors.
-func (e *Embeddings) cosineSimilarity(a, b []float32) (float64, error) {
-	if len(a) != len(b) {
-		return 0, fmt.Errorf("vectors must have the same length")
+// post sends a JSON payload to the worker and decodes its response.
+func (e *Embeddings) post(payload interface{}) (*embeddingResponse, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %v", err)
 	}
 
-	var dotProduct, aMagnitude, bMagnitude float64
-	for i := 0; i < len(a); i++ {
-		dotProduct += float64(a[i] * b[i])
-		aMagnitude += float64(a[i] * a[i])
-		bMagnitude += float64(b[i] * b[i])
+	// Make HTTP POST request to Cloudflare Worker
+	resp, err := doWithRetry(e.client, e.config, func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", e.url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	defer resp.Body.Close()
 
-	if aMagnitude == 0 || bMagnitude == 0 {
-		return 0, nil
+	// Parse response
+	var result embeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %v", err)
 	}
 
-	return dotProduct / (math.Sqrt(aMagnitude) * math.Sqrt(bMagnitude)), nil
+	return &result, nil
 }
 
 // GetSimilarity computes similarity between a query and precomputed embeddings.
 func (e *Embeddings) GetSimilarity(queryEmbedding []float32, contentEmbedding []float32) (float64, error) {
-	// Compute cosine similarity
-	return e.cosineSimilarity(queryEmbedding, contentEmbedding)
+	return vector.CosineSimilarity(queryEmbedding, contentEmbedding)
 }
 
 // Marshal serializes embeddings to JSON string.
@@ -118,4 +171,4 @@ func (e *Embeddings) Unmarshal(data string) ([]float32, error) {
 		return nil, fmt.Errorf("failed to unmarshal embeddings: %v", err)
 	}
 	return embeddings, nil
-}
\ No newline at end of file
+}
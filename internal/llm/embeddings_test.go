@@ -0,0 +1,140 @@
+package llm
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// testWorker serves embeddings like the pons worker. Each text is embedded as its
+// length, so responses can be matched to inputs. When status is set, batch requests
+// are answered with it instead.
+type testWorker struct {
+	*httptest.Server
+	mu      sync.Mutex
+	status  int
+	batches int
+	singles int
+	shortBy int
+}
+
+func newTestWorker(t *testing.T) *testWorker {
+	t.Helper()
+	worker := &testWorker{}
+	worker.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Text json.RawMessage `json:"text"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		worker.mu.Lock()
+		defer worker.mu.Unlock()
+		var texts []string
+		if json.Unmarshal(payload.Text, &texts) == nil {
+			worker.batches++
+			if worker.status != 0 {
+				http.Error(w, "batch failed", worker.status)
+				return
+			}
+			texts = texts[:len(texts)-worker.shortBy]
+		} else {
+			var text string
+			json.Unmarshal(payload.Text, &text)
+			texts = []string{text}
+			worker.singles++
+		}
+
+		data := make([][]float32, len(texts))
+		for i, text := range texts {
+			data[i] = []float32{float32(len(text))}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	t.Cleanup(worker.Close)
+	return worker
+}
+
+// testConfig returns a configuration that doesn't retry, so failures surface at once.
+func testConfig() EmbeddingsConfig {
+	config := DefaultEmbeddingsConfig()
+	config.MaxRetries = 0
+	return config
+}
+
+// checkLengths fails unless embeddings holds the length of each text, in order.
+func checkLengths(t *testing.T, embeddings [][]float32, texts []string) {
+	t.Helper()
+	if len(embeddings) != len(texts) {
+		t.Fatalf("got %d embeddings for %d texts", len(embeddings), len(texts))
+	}
+	for i, text := range texts {
+		if embeddings[i][0] != float32(len(text)) {
+			t.Errorf("embedding %d is %v, want the one for %q", i, embeddings[i], text)
+		}
+	}
+}
+
+func TestGenerateEmbeddingsBatch(t *testing.T) {
+	worker := newTestWorker(t)
+	e := NewEmbeddingsWithConfig(worker.URL, testConfig())
+	texts := []string{"a", "bb", "ccc"}
+
+	embeddings, err := e.GenerateEmbeddingsBatch(texts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkLengths(t, embeddings, texts)
+	if worker.batches != 1 || worker.singles != 0 {
+		t.Errorf("sent %d batch and %d single requests, want one batch", worker.batches, worker.singles)
+	}
+}
+
+func TestGenerateEmbeddingsBatchFallback(t *testing.T) {
+	texts := []string{"a", "bb", "ccc"}
+	for name, setup := range map[string]func(*testWorker){
+		"rejected":    func(w *testWorker) { w.status = http.StatusBadRequest },
+		"not found":   func(w *testWorker) { w.status = http.StatusNotFound },
+		"too large":   func(w *testWorker) { w.status = http.StatusRequestEntityTooLarge },
+		"wrong count": func(w *testWorker) { w.shortBy = 1 },
+	} {
+		t.Run(name, func(t *testing.T) {
+			worker := newTestWorker(t)
+			setup(worker)
+			e := NewEmbeddingsWithConfig(worker.URL, testConfig())
+
+			embeddings, err := e.GenerateEmbeddingsBatch(texts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkLengths(t, embeddings, texts)
+
+			// Later batches skip straight to single requests
+			if _, err := e.GenerateEmbeddingsBatch(texts); err != nil {
+				t.Fatal(err)
+			}
+			if worker.batches != 1 || worker.singles != 2*len(texts) {
+				t.Errorf("sent %d batch and %d single requests, want 1 and %d", worker.batches, worker.singles, 2*len(texts))
+			}
+		})
+	}
+}
+
+func TestGenerateEmbeddingsBatchReturnsOtherErrors(t *testing.T) {
+	for _, status := range []int{http.StatusUnauthorized, http.StatusTooManyRequests, http.StatusInternalServerError} {
+		worker := newTestWorker(t)
+		worker.status = status
+		e := NewEmbeddingsWithConfig(worker.URL, testConfig())
+
+		if _, err := e.GenerateEmbeddingsBatch([]string{"a", "bb"}); err == nil {
+			t.Errorf("status %d: batch succeeded", status)
+		}
+		if worker.singles != 0 || e.noBatch.Load() {
+			t.Errorf("status %d: fell back to single requests", status)
+		}
+	}
+}
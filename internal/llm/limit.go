@@ -0,0 +1,42 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"unicode/utf8"
+)
+
+// ErrInputTooLong is returned when a text to embed is longer than the configured
+// MaxInputChars and TruncateInput is not set. Callers can split the text into smaller
+// chunks and embed those instead.
+var ErrInputTooLong = errors.New("embedding input too long")
+
+// limitInput applies config.MaxInputChars to text, truncating it with a logged warning
+// when config.TruncateInput is set and returning ErrInputTooLong otherwise.
+func limitInput(config EmbeddingsConfig, text string) (string, error) {
+	if config.MaxInputChars <= 0 {
+		return text, nil
+	}
+	length := utf8.RuneCountInString(text)
+	if length <= config.MaxInputChars {
+		return text, nil
+	}
+	if !config.TruncateInput {
+		return "", fmt.Errorf("%w: %d characters, the limit is %d", ErrInputTooLong, length, config.MaxInputChars)
+	}
+	log.Printf("Warning: truncating embedding input from %d to %d characters", length, config.MaxInputChars)
+	return string([]rune(text)[:config.MaxInputChars]), nil
+}
+
+// limitInputs applies limitInput to each of texts, returning a new slice.
+func limitInputs(config EmbeddingsConfig, texts []string) ([]string, error) {
+	limited := make([]string, len(texts))
+	for i, text := range texts {
+		var err error
+		if limited[i], err = limitInput(config, text); err != nil {
+			return nil, fmt.Errorf("text %d: %w", i, err)
+		}
+	}
+	return limited, nil
+}
@@ -0,0 +1,59 @@
+package llm
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLimitInput(t *testing.T) {
+	config := EmbeddingsConfig{MaxInputChars: 4}
+
+	if got, err := limitInput(config, "héll"); err != nil || got != "héll" {
+		t.Errorf("input at the limit = %q, %v; want it unchanged", got, err)
+	}
+	if _, err := limitInput(config, "héllo"); !errors.Is(err, ErrInputTooLong) {
+		t.Errorf("input over the limit returned %v, want ErrInputTooLong", err)
+	}
+
+	config.TruncateInput = true
+	if got, err := limitInput(config, "héllo"); err != nil || got != "héll" {
+		t.Errorf("truncated input = %q, %v; want \"héll\"", got, err)
+	}
+
+	if got, err := limitInput(EmbeddingsConfig{}, "héllo"); err != nil || got != "héllo" {
+		t.Errorf("input without a limit = %q, %v; want it unchanged", got, err)
+	}
+}
+
+func TestLimitInputs(t *testing.T) {
+	config := EmbeddingsConfig{MaxInputChars: 3}
+	_, err := limitInputs(config, []string{"abc", "abcd"})
+	if !errors.Is(err, ErrInputTooLong) || !strings.HasPrefix(err.Error(), "text 1:") {
+		t.Errorf("limitInputs = %v, want ErrInputTooLong for text 1", err)
+	}
+
+	config.TruncateInput = true
+	texts := []string{"abc", "abcd"}
+	limited, err := limitInputs(config, texts)
+	if err != nil || limited[0] != "abc" || limited[1] != "abc" {
+		t.Errorf("limitInputs = %q, %v; want both cut to 3 characters", limited, err)
+	}
+	if texts[1] != "abcd" {
+		t.Error("limitInputs modified its input")
+	}
+}
+
+func TestBatchRespectsInputLimit(t *testing.T) {
+	worker := newTestWorker(t)
+	config := testConfig()
+	config.MaxInputChars = 2
+	e := NewEmbeddingsWithConfig(worker.URL, config)
+
+	if _, err := e.GenerateEmbeddingsBatch([]string{"a", "bbb"}); !errors.Is(err, ErrInputTooLong) {
+		t.Errorf("batch with an oversize text returned %v, want ErrInputTooLong", err)
+	}
+	if worker.batches+worker.singles != 0 {
+		t.Error("sent an oversize text to the worker")
+	}
+}
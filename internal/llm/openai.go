@@ -0,0 +1,141 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// DefaultOpenAIBaseURL is the API base used when no base URL is configured.
+const DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// OpenAIEmbeddings generates embeddings with an OpenAI-compatible /embeddings endpoint,
+// such as OpenAI itself, Ollama or LocalAI.
+type OpenAIEmbeddings struct {
+	client *http.Client
+	url    string
+	model  string
+	apiKey string
+	config EmbeddingsConfig
+}
+
+// NewOpenAIEmbeddings creates an embedder for the API at baseURL (e.g. "http://localhost:11434/v1"),
+// using model. An empty baseURL selects DefaultOpenAIBaseURL, and an empty apiKey sends no
+// Authorization header, which local servers usually don't need. Requests use
+// DefaultEmbeddingsConfig.
+func NewOpenAIEmbeddings(baseURL, model, apiKey string) *OpenAIEmbeddings {
+	return NewOpenAIEmbeddingsWithConfig(baseURL, model, apiKey, DefaultEmbeddingsConfig())
+}
+
+// NewOpenAIEmbeddingsWithConfig is NewOpenAIEmbeddings with the given request timeout and
+// retry policy.
+func NewOpenAIEmbeddingsWithConfig(baseURL, model, apiKey string, config EmbeddingsConfig) *OpenAIEmbeddings {
+	if baseURL == "" {
+		baseURL = DefaultOpenAIBaseURL
+	}
+	url := strings.TrimSuffix(baseURL, "/")
+	if !strings.HasSuffix(url, "/embeddings") {
+		url += "/embeddings"
+	}
+	return &OpenAIEmbeddings{
+		client: newHTTPClient(config),
+		url:    url,
+		model:  model,
+		apiKey: apiKey,
+		config: config,
+	}
+}
+
+// openAIEmbeddingResponse matches the /embeddings response body.
+type openAIEmbeddingResponse struct {
+	Data []struct {
+		Embedding []float32 `json:"embedding"`
+		Index     int       `json:"index"`
+	} `json:"data"`
+}
+
+// Model returns the model name together with the endpoint serving it.
+func (e *OpenAIEmbeddings) Model() string {
+	return e.model + "@" + e.url
+}
+
+// GenerateEmbeddings sends content to the embeddings endpoint and returns its embedding.
+func (e *OpenAIEmbeddings) GenerateEmbeddings(content string) ([]float32, error) {
+	content, err := limitInput(e.config, content)
+	if err != nil {
+		return nil, err
+	}
+	result, err := e.post(content)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.Data) == 0 || len(result.Data[0].Embedding) == 0 {
+		return nil, fmt.Errorf("empty embedding returned")
+	}
+
+	return result.Data[0].Embedding, nil
+}
+
+// GenerateEmbeddingsBatch embeds all texts in one request and returns their embeddings
+// in input order.
+func (e *OpenAIEmbeddings) GenerateEmbeddingsBatch(texts []string) ([][]float32, error) {
+	texts, err := limitInputs(e.config, texts)
+	if err != nil {
+		return nil, err
+	}
+	result, err := e.post(texts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(result.Data))
+	}
+
+	// The API reports each embedding's input position; don't rely on response order
+	sort.Slice(result.Data, func(i, j int) bool { return result.Data[i].Index < result.Data[j].Index })
+	embeddings := make([][]float32, len(texts))
+	for i, data := range result.Data {
+		if len(data.Embedding) == 0 {
+			return nil, fmt.Errorf("empty embedding returned")
+		}
+		embeddings[i] = data.Embedding
+	}
+	return embeddings, nil
+}
+
+// post sends input, a string or a slice of strings, to the embeddings endpoint and
+// decodes its response.
+func (e *OpenAIEmbeddings) post(input interface{}) (*openAIEmbeddingResponse, error) {
+	body, err := json.Marshal(map[string]interface{}{"input": input, "model": e.model})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %v", err)
+	}
+
+	resp, err := doWithRetry(e.client, e.config, func() (*http.Request, error) {
+		req, err := http.NewRequest("POST", e.url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if e.apiKey != "" {
+			req.Header.Set("Authorization", "Bearer "+e.apiKey)
+		}
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result openAIEmbeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %v", err)
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,80 @@
+package llm
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestOpenAI serves an OpenAI-compatible /embeddings endpoint that embeds each input
+// as its length and answers with the results in reverse order.
+func newTestOpenAI(t *testing.T, wantKey string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" {
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.Header.Get("Authorization"); got != wantKey {
+			t.Errorf("Authorization is %q, want %q", got, wantKey)
+		}
+		var payload struct {
+			Input json.RawMessage `json:"input"`
+			Model string          `json:"model"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || payload.Model != "test-model" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		var inputs []string
+		if json.Unmarshal(payload.Input, &inputs) != nil {
+			var input string
+			json.Unmarshal(payload.Input, &input)
+			inputs = []string{input}
+		}
+
+		type item struct {
+			Embedding []float32 `json:"embedding"`
+			Index     int       `json:"index"`
+		}
+		data := make([]item, 0, len(inputs))
+		for i := len(inputs) - 1; i >= 0; i-- {
+			data = append(data, item{Embedding: []float32{float32(len(inputs[i]))}, Index: i})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOpenAIEmbeddings(t *testing.T) {
+	server := newTestOpenAI(t, "Bearer secret")
+	e := NewOpenAIEmbeddingsWithConfig(server.URL+"/v1/", "test-model", "secret", testConfig())
+	if want := "test-model@" + server.URL + "/v1/embeddings"; e.Model() != want {
+		t.Errorf("Model is %q, want %q", e.Model(), want)
+	}
+
+	embedding, err := e.GenerateEmbeddings("abcd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(embedding) != 1 || embedding[0] != 4 {
+		t.Errorf("embedding is %v, want [4]", embedding)
+	}
+
+	texts := []string{"a", "bb", "ccc"}
+	embeddings, err := e.GenerateEmbeddingsBatch(texts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkLengths(t, embeddings, texts)
+}
+
+func TestOpenAIEmbeddingsWithoutKey(t *testing.T) {
+	server := newTestOpenAI(t, "")
+	e := NewOpenAIEmbeddingsWithConfig(server.URL+"/v1/embeddings", "test-model", "", testConfig())
+	if _, err := e.GenerateEmbeddings("abcd"); err != nil {
+		t.Fatal(err)
+	}
+}
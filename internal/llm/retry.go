@@ -0,0 +1,121 @@
+package llm
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// EmbeddingsConfig controls how embedding requests are sent.
+type EmbeddingsConfig struct {
+	// Timeout bounds each request, including reading the response; 0 disables it
+	Timeout time.Duration
+	// MaxRetries is how many times a request is retried after a network error,
+	// a 429 or a 5xx response
+	MaxRetries int
+	// RetryBackoff is the wait before the first retry, doubled for each later one.
+	// A Retry-After header sent by the server takes precedence.
+	RetryBackoff time.Duration
+	// UserAgent, when set, is sent as the User-Agent header of every request
+	UserAgent string
+	// Proxy, when set, is the proxy every request goes through. Otherwise the
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are honored.
+	Proxy *url.URL
+	// MaxInputChars, when positive, is the longest text in characters sent for embedding,
+	// so oversize input fails clearly instead of being cut off or rejected by the model
+	MaxInputChars int
+	// TruncateInput truncates texts longer than MaxInputChars, logging a warning, instead
+	// of returning ErrInputTooLong
+	TruncateInput bool
+}
+
+// newHTTPClient creates the HTTP client embedders send their requests with.
+func newHTTPClient(config EmbeddingsConfig) *http.Client {
+	client := &http.Client{Timeout: config.Timeout}
+	if config.Proxy != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = http.ProxyURL(config.Proxy)
+		client.Transport = transport
+	}
+	return client
+}
+
+// DefaultEmbeddingsConfig returns the configuration used by NewEmbeddings and
+// NewOpenAIEmbeddings.
+func DefaultEmbeddingsConfig() EmbeddingsConfig {
+	return EmbeddingsConfig{
+		Timeout:      30 * time.Second,
+		MaxRetries:   3,
+		RetryBackoff: 500 * time.Millisecond,
+	}
+}
+
+// maxRetryWait caps how long a single Retry-After header can make us wait.
+const maxRetryWait = time.Minute
+
+// doWithRetry sends the request built by newRequest, retrying transient failures as
+// described by config. newRequest is called once per attempt so each gets a fresh body.
+// The returned response always has a 200 OK status; any other final status is
+// returned as a *statusError.
+func doWithRetry(client *http.Client, config EmbeddingsConfig, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	backoff := config.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %v", err)
+		}
+		if config.UserAgent != "" {
+			req.Header.Set("User-Agent", config.UserAgent)
+		}
+
+		resp, err := client.Do(req)
+		var wait time.Duration
+		if err != nil {
+			if attempt >= config.MaxRetries {
+				return nil, fmt.Errorf("failed to send request: %v", err)
+			}
+		} else {
+			if resp.StatusCode == http.StatusOK {
+				return resp, nil
+			}
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if !retryable(resp.StatusCode) || attempt >= config.MaxRetries {
+				return nil, &statusError{code: resp.StatusCode, body: string(bodyBytes)}
+			}
+			wait = retryAfter(resp.Header.Get("Retry-After"), time.Now())
+		}
+
+		if wait <= 0 {
+			wait = backoff
+		}
+		time.Sleep(wait)
+		backoff *= 2
+	}
+}
+
+// retryable reports whether a response status is worth retrying.
+func retryable(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// retryAfter parses a Retry-After header, given either in seconds or as an HTTP date,
+// and returns 0 when it is missing or invalid.
+func retryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	var wait time.Duration
+	if seconds, err := strconv.Atoi(header); err == nil {
+		wait = time.Duration(seconds) * time.Second
+	} else if at, err := http.ParseTime(header); err == nil {
+		wait = at.Sub(now)
+	}
+	if wait > maxRetryWait {
+		wait = maxRetryWait
+	}
+	return wait
+}
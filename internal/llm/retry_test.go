@@ -0,0 +1,71 @@
+package llm
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	for header, want := range map[string]time.Duration{
+		"":                              0,
+		"3":                             3 * time.Second,
+		"600":                           maxRetryWait,
+		"soon":                          0,
+		"Tue, 02 Jan 2024 15:04:15 GMT": 10 * time.Second,
+		"Tue, 02 Jan 2024 15:00:00 GMT": -245 * time.Second,
+	} {
+		if got := retryAfter(header, now); got != want {
+			t.Errorf("retryAfter(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
+
+func TestDoWithRetry(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		statuses []int
+		retries  int
+		want     int
+		requests int
+	}{
+		{"success", []int{200}, 3, 200, 1},
+		{"server error then success", []int{503, 500, 200}, 3, 200, 3},
+		{"rate limited then success", []int{429, 200}, 3, 200, 2},
+		{"retries exhausted", []int{500, 500, 500}, 2, 500, 3},
+		{"client error not retried", []int{400, 200}, 3, 400, 1},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("User-Agent") != "pons-test" {
+					t.Errorf("User-Agent is %q", r.Header.Get("User-Agent"))
+				}
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(test.statuses[requests])
+				requests++
+			}))
+			defer server.Close()
+
+			config := EmbeddingsConfig{MaxRetries: test.retries, RetryBackoff: time.Millisecond, UserAgent: "pons-test"}
+			resp, err := doWithRetry(server.Client(), config, func() (*http.Request, error) {
+				return http.NewRequest("GET", server.URL, nil)
+			})
+			code := 0
+			if err == nil {
+				code = resp.StatusCode
+				resp.Body.Close()
+			} else if status, ok := err.(*statusError); ok {
+				code = status.code
+			} else {
+				t.Fatal(err)
+			}
+
+			if code != test.want || requests != test.requests {
+				t.Errorf("got status %d after %d requests, want %d after %d", code, requests, test.want, test.requests)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// DefaultEmbeddingTemplate prepends the document title and context to its content.
+const DefaultEmbeddingTemplate = "Title: {{.Title}}\nContext: {{.Context}}\n\n{{.Content}}"
+
+// EmbeddingInput holds the fields available to an embedding template.
+type EmbeddingInput struct {
+	Title   string
+	Context string
+	Content string
+}
+
+// RenderEmbeddingInput builds the text that is sent for embedding.
+// The stored content is never changed; only the embedded text is augmented.
+// An empty template embeds the content as-is, and "default" selects DefaultEmbeddingTemplate.
+func RenderEmbeddingInput(tmpl string, input EmbeddingInput) (string, error) {
+	switch tmpl {
+	case "":
+		return input.Content, nil
+	case "default":
+		tmpl = DefaultEmbeddingTemplate
+	}
+
+	t, err := template.New("embedding").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("invalid embedding template: %v", err)
+	}
+
+	var b strings.Builder
+	if err := t.Execute(&b, input); err != nil {
+		return "", fmt.Errorf("failed to render embedding template: %v", err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,111 @@
+package scraper
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// maxDelayFactor bounds how far the adaptive tuner stretches Config.RequestDelay.
+const maxDelayFactor = 8
+
+// adaptiveTuner adjusts crawl concurrency and request spacing from observed responses.
+//
+// It follows an additive-increase/multiplicative-decrease scheme: it starts at
+// Config.MinConcurrent, allows one more concurrent request after every run of
+// successful fetches as long as the current limit, and halves the limit (and
+// doubles the request delay) whenever a fetch is throttled or fails transiently.
+type adaptiveTuner struct {
+	mu          sync.Mutex
+	limit       int
+	min         int
+	max         int
+	inUse       int
+	successes   int
+	delayFactor int
+	// changed is closed and replaced whenever a slot may have become available
+	changed chan struct{}
+}
+
+func newAdaptiveTuner(min, max int) *adaptiveTuner {
+	if min < 1 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+	return &adaptiveTuner{
+		limit:       min,
+		min:         min,
+		max:         max,
+		delayFactor: 1,
+		changed:     make(chan struct{}),
+	}
+}
+
+// acquire blocks until the current concurrency limit allows another request.
+func (t *adaptiveTuner) acquire(ctx context.Context) error {
+	for {
+		t.mu.Lock()
+		if t.inUse < t.limit {
+			t.inUse++
+			t.mu.Unlock()
+			return nil
+		}
+		changed := t.changed
+		t.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// release frees a slot taken by acquire.
+func (t *adaptiveTuner) release() {
+	t.mu.Lock()
+	t.inUse--
+	t.broadcast()
+	t.mu.Unlock()
+}
+
+// record feeds the outcome of a fetch back into the tuner.
+func (t *adaptiveTuner) record(throttled bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if throttled {
+		t.successes = 0
+		t.limit = max(t.min, t.limit/2)
+		t.delayFactor = min(maxDelayFactor, t.delayFactor*2)
+		return
+	}
+
+	t.successes++
+	if t.successes < t.limit {
+		return
+	}
+	t.successes = 0
+	if t.delayFactor > 1 {
+		t.delayFactor /= 2
+	}
+	if t.limit < t.max {
+		t.limit++
+		t.broadcast()
+	}
+}
+
+// delay scales the configured request delay by the current backoff factor.
+func (t *adaptiveTuner) delay(base time.Duration) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return base * time.Duration(t.delayFactor)
+}
+
+// broadcast wakes every goroutine waiting in acquire. The caller must hold t.mu.
+func (t *adaptiveTuner) broadcast() {
+	close(t.changed)
+	t.changed = make(chan struct{})
+}
@@ -0,0 +1,89 @@
+package scraper
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// DefaultStripSelectors lists the page furniture removed before text extraction.
+// See Config.StripSelectors for the selector syntax.
+var DefaultStripSelectors = []string{
+	"nav", "header", "footer", "aside",
+	"[role=navigation]", "[role=banner]", "[role=contentinfo]", "[role=complementary]",
+	".sidebar", ".menu", ".cookie", ".navbar", ".breadcrumb",
+}
+
+// stripBoilerplate removes every descendant of n matching one of the selectors.
+//
+// Supported selectors are a tag name ("nav"), a class fragment (".sidebar" matches any
+// element with a class containing "sidebar", such as "docs-sidebar"), an id ("#menu")
+// and an attribute value ("[role=navigation]").
+func stripBoilerplate(n *html.Node, selectors []string) {
+	if len(selectors) == 0 {
+		return
+	}
+
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.ElementNode && c.Data != "body" && c.Data != "html" && matchesAnySelector(c, selectors) {
+			n.RemoveChild(c)
+		} else {
+			stripBoilerplate(c, selectors)
+		}
+		c = next
+	}
+}
+
+// matchesAnySelector reports whether an element matches any of the selectors.
+func matchesAnySelector(n *html.Node, selectors []string) bool {
+	for _, selector := range selectors {
+		if matchesSelector(n, strings.TrimSpace(selector)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesSelector reports whether an element matches a single selector.
+func matchesSelector(n *html.Node, selector string) bool {
+	switch {
+	case selector == "":
+		return false
+	case strings.HasPrefix(selector, "."):
+		name := strings.ToLower(selector[1:])
+		for _, class := range strings.Fields(attr(n, "class")) {
+			if strings.Contains(strings.ToLower(class), name) {
+				return true
+			}
+		}
+		return false
+	case strings.HasPrefix(selector, "#"):
+		return attr(n, "id") == selector[1:]
+	case strings.HasPrefix(selector, "[") && strings.HasSuffix(selector, "]"):
+		key, value, hasValue := strings.Cut(selector[1:len(selector)-1], "=")
+		actual, ok := attrOK(n, strings.TrimSpace(key))
+		if !hasValue {
+			return ok
+		}
+		return ok && strings.EqualFold(actual, strings.Trim(strings.TrimSpace(value), `"'`))
+	default:
+		return strings.EqualFold(n.Data, selector)
+	}
+}
+
+// attr returns the value of an element's attribute, or "" when it is absent.
+func attr(n *html.Node, key string) string {
+	value, _ := attrOK(n, key)
+	return value
+}
+
+// attrOK returns the value of an element's attribute and whether it is present.
+func attrOK(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,71 @@
+package scraper
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestStripBoilerplate(t *testing.T) {
+	page := `<html><body>
+<header><a href="/">Site</a></header>
+<nav>Home Docs Blog</nav>
+<div class="docs-sidebar">Sidebar links</div>
+<div id="menu">Menu</div>
+<div role="banner">Banner</div>
+<main>
+  <h1>Guide</h1>
+  <p>Install the tool.</p>
+  <div class="cookie-consent">We use cookies</div>
+</main>
+<footer>Copyright</footer>
+</body></html>`
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stripBoilerplate(doc, append(DefaultStripSelectors, "#menu"))
+
+	text := extractText(doc)
+	for _, kept := range []string{"Guide", "Install the tool."} {
+		if !strings.Contains(text, kept) {
+			t.Errorf("content %q was stripped: %q", kept, text)
+		}
+	}
+	for _, stripped := range []string{"Site", "Home Docs Blog", "Sidebar links", "Menu", "Banner", "We use cookies", "Copyright"} {
+		if strings.Contains(text, stripped) {
+			t.Errorf("boilerplate %q was kept: %q", stripped, text)
+		}
+	}
+}
+
+func TestParserStripsBoilerplate(t *testing.T) {
+	page := `<html><body><nav>Home Docs</nav><main><h1 id="intro">Intro</h1><p>Welcome.</p>` +
+		`<div class="cookie">Accept cookies</div></main><footer>Copyright</footer></body></html>`
+	parser := Parser{StripSelectors: DefaultStripSelectors}
+
+	markdown, err := parser.ToMarkdown(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sections, err := parser.ToSections(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var all strings.Builder
+	for _, section := range sections {
+		all.WriteString(section.Markdown)
+	}
+
+	for name, got := range map[string]string{"ToMarkdown": markdown, "ToSections": all.String()} {
+		if !strings.Contains(got, "Welcome.") {
+			t.Errorf("%s dropped the content: %q", name, got)
+		}
+		for _, stripped := range []string{"Home Docs", "Accept cookies", "Copyright"} {
+			if strings.Contains(got, stripped) {
+				t.Errorf("%s kept %q: %q", name, stripped, got)
+			}
+		}
+	}
+}
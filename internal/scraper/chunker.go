@@ -0,0 +1,162 @@
+package scraper
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ChunkMarkdown splits Markdown into chunks of at most size characters for embedding.
+//
+// Chunks break on paragraph boundaries, preferring to start a new chunk at a heading,
+// and fenced code blocks are kept whole where they fit. Consecutive chunks share up to
+// overlap characters of trailing paragraphs so context isn't lost at the cut. A single
+// paragraph longer than size is split at whitespace.
+//
+// Parameters:
+//   - markdown: The Markdown content to split
+//   - size: The maximum chunk length in characters (0 or less disables chunking)
+//   - overlap: How many characters of the previous chunk to repeat at the start of the next
+//
+// Returns:
+//   - The chunks in document order; content that fits in one chunk is returned as is
+func ChunkMarkdown(markdown string, size, overlap int) []string {
+	markdown = strings.TrimSpace(markdown)
+	if size <= 0 || len(markdown) <= size {
+		return []string{markdown}
+	}
+	if overlap < 0 {
+		overlap = 0
+	}
+	if overlap > size/2 {
+		overlap = size / 2
+	}
+
+	var chunks []string
+	var current []string
+	currentLen, fresh := 0, 0
+
+	flush := func() {
+		chunks = append(chunks, strings.Join(current, "\n\n"))
+		current = overlapTail(current, overlap)
+		currentLen = joinedLen(current)
+		fresh = 0
+	}
+
+	for _, block := range markdownBlocks(markdown, size) {
+		if fresh > 0 {
+			full := currentLen+2+len(block) > size
+			headingBreak := strings.HasPrefix(block, "#") && currentLen >= size/2
+			if full || headingBreak {
+				flush()
+			}
+		}
+		// Drop the overlap when it would push the block over the limit
+		if len(current) > 0 && currentLen+2+len(block) > size {
+			current, currentLen = nil, 0
+		}
+		if len(current) > 0 {
+			currentLen += 2
+		}
+		current = append(current, block)
+		currentLen += len(block)
+		fresh++
+	}
+	if fresh > 0 {
+		chunks = append(chunks, strings.Join(current, "\n\n"))
+	}
+	return chunks
+}
+
+// ChunkURLs returns the document URLs for the chunks of a page: the URL itself for a
+// single chunk, otherwise url#chunk0, url#chunk1, and so on.
+func ChunkURLs(url string, count int) []string {
+	if count <= 1 {
+		return []string{url}
+	}
+	urls := make([]string, count)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("%s#chunk%d", url, i)
+	}
+	return urls
+}
+
+// markdownBlocks splits Markdown into paragraphs no longer than size, keeping fenced
+// code blocks together.
+func markdownBlocks(markdown string, size int) []string {
+	var blocks []string
+	var fence []string
+
+	for _, paragraph := range strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+
+		// Collect paragraphs until an unterminated ``` fence closes
+		if fence != nil || strings.Count(paragraph, "```")%2 == 1 {
+			fence = append(fence, paragraph)
+			if len(fence) > 1 && strings.Count(paragraph, "```")%2 == 1 {
+				blocks = append(blocks, splitLong(strings.Join(fence, "\n\n"), size)...)
+				fence = nil
+			}
+			continue
+		}
+
+		blocks = append(blocks, splitLong(paragraph, size)...)
+	}
+	if fence != nil {
+		blocks = append(blocks, splitLong(strings.Join(fence, "\n\n"), size)...)
+	}
+	return blocks
+}
+
+// splitLong cuts text longer than size into pieces, breaking at the last whitespace before the limit.
+func splitLong(text string, size int) []string {
+	var pieces []string
+	for len(text) > size {
+		cut := strings.LastIndexAny(text[:size], " \n\t")
+		if cut <= 0 {
+			// No whitespace to break at; cut on a character boundary
+			cut = size
+			for cut > 1 && !utf8.RuneStart(text[cut]) {
+				cut--
+			}
+		}
+		pieces = append(pieces, strings.TrimSpace(text[:cut]))
+		text = strings.TrimSpace(text[cut:])
+	}
+	if text != "" {
+		pieces = append(pieces, text)
+	}
+	return pieces
+}
+
+// overlapTail returns the trailing blocks whose joined length fits in overlap.
+func overlapTail(blocks []string, overlap int) []string {
+	start, length := len(blocks), 0
+	for start > 0 {
+		next := length + len(blocks[start-1])
+		if length > 0 {
+			next += 2
+		}
+		if next > overlap {
+			break
+		}
+		length = next
+		start--
+	}
+	return append([]string(nil), blocks[start:]...)
+}
+
+// joinedLen is the length of blocks joined by blank lines.
+func joinedLen(blocks []string) int {
+	if len(blocks) == 0 {
+		return 0
+	}
+	length := 2 * (len(blocks) - 1)
+	for _, block := range blocks {
+		length += len(block)
+	}
+	return length
+}
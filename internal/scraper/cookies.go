@@ -0,0 +1,143 @@
+package scraper
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// httpOnlyPrefix marks HttpOnly cookies in Netscape cookie files, which would otherwise
+// be comment lines.
+const httpOnlyPrefix = "#HttpOnly_"
+
+// jarCookie is a cookie together with a URL it can be set from.
+type jarCookie struct {
+	url    *url.URL
+	cookie *http.Cookie
+}
+
+// newCookieJar returns a cookie jar holding config.Cookies and the cookies of
+// config.CookieFile, so each cookie is only sent to the hosts it matches and cookies set
+// by the site are kept for the rest of the crawl.
+//
+// Parameters:
+//   - config: The configuration holding the cookies
+//   - startURL: The URL the crawl starts from, whose host receives cookies without a Domain
+//
+// Returns:
+//   - The cookie jar
+//   - An error if the start URL is invalid or the cookie file can't be read
+func newCookieJar(config *Config, startURL string) (http.CookieJar, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cookie jar: %w", err)
+	}
+	start, err := url.Parse(startURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	var cookies []jarCookie
+	for _, cookie := range config.Cookies {
+		host := cookie.Domain
+		if host == "" {
+			host = start.Hostname()
+		}
+		cookies = append(cookies, jarCookie{url: cookieURL(host, cookie), cookie: cookie})
+	}
+	if config.CookieFile != "" {
+		fileCookies, err := readCookieFile(config.CookieFile)
+		if err != nil {
+			return nil, err
+		}
+		cookies = append(cookies, fileCookies...)
+	}
+
+	for _, c := range cookies {
+		jar.SetCookies(c.url, []*http.Cookie{c.cookie})
+	}
+	return jar, nil
+}
+
+// readCookieFile reads cookies from a Netscape-format cookie file, as exported by
+// browser extensions and written by curl's --cookie-jar. Each line holds seven
+// tab-separated fields: domain, whether subdomains match, path, whether the cookie is
+// secure, expiry as a Unix timestamp (0 for a session cookie), name and value.
+// Cookies that don't match subdomains are host-only. Expired cookies are skipped.
+//
+// Parameters:
+//   - path: The path of the cookie file
+//
+// Returns:
+//   - The cookies in the file, in file order
+//   - An error if the file can't be read or a line is malformed
+func readCookieFile(path string) ([]jarCookie, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cookie file: %w", err)
+	}
+	defer file.Close()
+
+	var cookies []jarCookie
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		httpOnly := strings.HasPrefix(line, httpOnlyPrefix)
+		line = strings.TrimPrefix(line, httpOnlyPrefix)
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("cookie file line %d: expected 7 tab-separated fields, got %d", lineNumber, len(fields))
+		}
+		expiry, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cookie file line %d: invalid expiry %q", lineNumber, fields[4])
+		}
+
+		host := strings.TrimPrefix(fields[0], ".")
+		cookie := &http.Cookie{
+			Name:     fields[5],
+			Value:    fields[6],
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			HttpOnly: httpOnly,
+		}
+		// A cookie without a Domain is host-only
+		if strings.EqualFold(fields[1], "TRUE") {
+			cookie.Domain = host
+		}
+		if expiry > 0 {
+			cookie.Expires = time.Unix(expiry, 0)
+			if cookie.Expires.Before(time.Now()) {
+				continue
+			}
+		}
+		cookies = append(cookies, jarCookie{url: cookieURL(host, cookie), cookie: cookie})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cookie file: %w", err)
+	}
+	return cookies, nil
+}
+
+// cookieURL returns a URL on host that cookie can be set from.
+func cookieURL(host string, cookie *http.Cookie) *url.URL {
+	scheme := "http"
+	if cookie.Secure {
+		scheme = "https"
+	}
+	path := cookie.Path
+	if path == "" {
+		path = "/"
+	}
+	return &url.URL{Scheme: scheme, Host: host, Path: path}
+}
@@ -0,0 +1,115 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// newTestScraper returns a scraper for url that doesn't wait between requests.
+func newTestScraper(t *testing.T, url string) *Scraper {
+	t.Helper()
+	config := DefaultConfig()
+	config.RequestDelay = 0
+	config.RetryBackoff = time.Millisecond
+	s, err := New(url, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestFetchURLRetriesTransientFailures(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= 2 {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><body><p>Recovered</p></body></html>"))
+	}))
+	defer server.Close()
+
+	s := newTestScraper(t, server.URL)
+	page, err := s.fetchURL(context.Background(), server.URL, Validators{})
+	if err != nil {
+		t.Fatalf("fetchURL failed after retries: %v", err)
+	}
+	if !strings.Contains(page.body, "Recovered") {
+		t.Errorf("got body %q, want the page served after the failures", page.body)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("server got %d requests, want 3", n)
+	}
+}
+
+func TestFetchURLGivesUpAfterMaxRetries(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Error(w, "down", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	s := newTestScraper(t, server.URL)
+	if _, err := s.fetchURL(context.Background(), server.URL, Validators{}); err == nil {
+		t.Fatal("fetchURL succeeded against a failing server")
+	}
+	if n, want := requests.Load(), int32(s.Config.MaxRetries+1); n != want {
+		t.Errorf("server got %d requests, want %d", n, want)
+	}
+}
+
+func TestFetchURLHonorsRetryAfter(t *testing.T) {
+	var requests atomic.Int32
+	var retried time.Time
+	start := time.Now()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		retried = time.Now()
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><body><p>OK</p></body></html>"))
+	}))
+	defer server.Close()
+
+	s := newTestScraper(t, server.URL)
+	if _, err := s.fetchURL(context.Background(), server.URL, Validators{}); err != nil {
+		t.Fatal(err)
+	}
+	// The configured backoff is a millisecond, so only Retry-After explains the wait
+	if wait := retried.Sub(start); wait < time.Second {
+		t.Errorf("retried after %v, want at least the 1s Retry-After", wait)
+	}
+}
+
+func TestFetchURLConvertsLatin1ToUTF8(t *testing.T) {
+	// "Café crème à la façon" encoded as ISO-8859-1: é, è, à and ç are single bytes
+	latin1 := []byte("<html><body><p>Caf\xe9 cr\xe8me \xe0 la fa\xe7on</p></body></html>")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=ISO-8859-1")
+		w.Write(latin1)
+	}))
+	defer server.Close()
+
+	s := newTestScraper(t, server.URL)
+	page, err := s.fetchURL(context.Background(), server.URL, Validators{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !utf8.ValidString(page.body) {
+		t.Fatalf("body is not valid UTF-8: %q", page.body)
+	}
+	if text := strings.TrimSpace(extractText(findMainContentNode(page.doc))); text != "Café crème à la façon" {
+		t.Errorf("parsed text is %q, want %q", text, "Café crème à la façon")
+	}
+}
@@ -0,0 +1,78 @@
+package scraper
+
+import (
+	"strings"
+	"unicode"
+)
+
+// languageStopwords holds very frequent words of the languages DetectLanguage knows.
+// Function words make up a large share of any running text, so counting them is
+// enough to tell these languages apart without a trained model.
+var languageStopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "in", "is", "that", "for", "it", "with", "as", "on", "this", "are", "be", "you", "by", "or", "from", "can", "an", "not", "which", "your", "if"},
+	"de": {"der", "die", "und", "das", "ist", "den", "nicht", "zu", "mit", "sich", "des", "auf", "für", "ein", "eine", "dem", "im", "auch", "es", "sie", "werden", "wird", "oder", "wenn", "kann"},
+	"fr": {"le", "la", "les", "et", "des", "est", "une", "un", "du", "dans", "pour", "que", "qui", "pas", "sur", "au", "avec", "ce", "sont", "vous", "par", "plus", "ou", "cette", "être"},
+	"es": {"el", "la", "los", "las", "y", "que", "de", "en", "es", "por", "un", "una", "para", "con", "no", "se", "del", "lo", "como", "más", "al", "su", "pero", "este", "puede"},
+	"it": {"il", "di", "che", "la", "è", "per", "un", "non", "una", "sono", "le", "gli", "con", "del", "della", "si", "da", "anche", "questo", "come", "nel", "alla", "dei", "può", "essere"},
+	"pt": {"o", "a", "os", "as", "de", "que", "e", "do", "da", "em", "um", "uma", "para", "com", "não", "por", "mais", "dos", "das", "se", "na", "no", "como", "ao", "pode"},
+	"nl": {"de", "het", "een", "en", "van", "is", "dat", "op", "te", "in", "voor", "met", "niet", "zijn", "er", "die", "ook", "aan", "om", "wordt", "kan", "bij", "als", "of", "naar"},
+}
+
+// languageMinWords is how many words a text needs before DetectLanguage guesses.
+const languageMinWords = 20
+
+// stopwordLanguages maps each stopword to the languages it belongs to.
+var stopwordLanguages = func() map[string][]string {
+	index := make(map[string][]string)
+	for language, words := range languageStopwords {
+		for _, word := range words {
+			index[word] = append(index[word], language)
+		}
+	}
+	return index
+}()
+
+// DetectLanguage guesses the primary language of a text from how often the most
+// common words of each known language occur in it.
+//
+// It recognizes English, German, French, Spanish, Italian, Portuguese and Dutch and is
+// meant as a fallback for pages that don't declare their language with <html lang>.
+//
+// Parameters:
+//   - text: The text to examine, e.g. a page's Markdown content
+//
+// Returns:
+//   - The language's primary subtag, e.g. "de", or "" when the text is too short or
+//     no language clearly stands out
+func DetectLanguage(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if len(words) < languageMinWords {
+		return ""
+	}
+
+	scores := make(map[string]int)
+	for _, word := range words {
+		for _, language := range stopwordLanguages[word] {
+			scores[language]++
+		}
+	}
+
+	best, bestScore, secondScore := "", 0, 0
+	for language, score := range scores {
+		switch {
+		case score > bestScore:
+			best, bestScore, secondScore = language, score, max(bestScore, secondScore)
+		case score > secondScore:
+			secondScore = score
+		}
+	}
+
+	// Require a fair share of stopwords and a clear lead over the runner-up, so code
+	// samples, word lists and mixed-language text stay unlabeled
+	if bestScore*10 < len(words) || bestScore*4 < secondScore*5 {
+		return ""
+	}
+	return best
+}
@@ -1,16 +1,152 @@
 package scraper
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
+
 	htm "github.com/JohannesKaufmann/html-to-markdown/v2"
+	"golang.org/x/net/html"
 )
 
-type Parser struct{}
+// Parser converts crawled HTML to Markdown.
+type Parser struct {
+	// StripSelectors pick out boilerplate removed from a page before it is converted;
+	// see Config.StripSelectors. Empty converts the whole page.
+	StripSelectors []string
+}
+
+// Section is a part of a page that starts at a heading with an id.
+type Section struct {
+	// Anchor is the heading's id, usable as a URL fragment (empty for content before the first heading)
+	Anchor string
+	// Heading is the text of the heading that starts the section
+	Heading string
+	// Markdown is the section's content, including its heading
+	Markdown string
+}
+
+// sectionMarker matches the placeholder lines ToSections inserts before each anchored heading.
+// It only uses characters the Markdown converter never escapes.
+var sectionMarker = regexp.MustCompile(`^PONSSECTION(\d+)X$`)
 
-// ToMarkdown converts HTML content to Markdown format.
+// ToMarkdown converts HTML content to Markdown format, leaving out anything matching
+// p.StripSelectors.
 func (p *Parser) ToMarkdown(htmlString string) (string, error) {
+	if len(p.StripSelectors) > 0 {
+		doc, err := html.Parse(strings.NewReader(htmlString))
+		if err != nil {
+			return "", fmt.Errorf("failed to parse HTML: %w", err)
+		}
+		stripBoilerplate(doc, p.StripSelectors)
+		var rendered strings.Builder
+		if err := html.Render(&rendered, doc); err != nil {
+			return "", fmt.Errorf("failed to render HTML: %w", err)
+		}
+		htmlString = rendered.String()
+	}
+
 	markdown, err := htm.ConvertString(htmlString)
 	if err != nil {
 		return "", err
 	}
 	return markdown, nil
 }
+
+// ToSections converts HTML content to Markdown split at every heading (h1-h6) that
+// carries an id, either on the heading itself or on an anchor inside it.
+//
+// Content before the first anchored heading is returned as a section without an anchor.
+// Empty sections are dropped, and so is anything matching p.StripSelectors.
+func (p *Parser) ToSections(htmlString string) ([]Section, error) {
+	doc, err := html.Parse(strings.NewReader(htmlString))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+	stripBoilerplate(doc, p.StripSelectors)
+
+	// Collect anchored headings first so inserting markers doesn't disturb the walk
+	var headings []*html.Node
+	var anchors []string
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && isHeading(n.Data) {
+			if anchor := headingAnchor(n); anchor != "" {
+				headings = append(headings, n)
+				anchors = append(anchors, anchor)
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	// Put a marker paragraph in front of each heading; it survives conversion as its own line
+	for i, heading := range headings {
+		marker := &html.Node{Type: html.ElementNode, Data: "p"}
+		marker.AppendChild(&html.Node{Type: html.TextNode, Data: fmt.Sprintf("PONSSECTION%dX", i)})
+		heading.Parent.InsertBefore(marker, heading)
+	}
+
+	var rendered strings.Builder
+	if err := html.Render(&rendered, doc); err != nil {
+		return nil, fmt.Errorf("failed to render HTML: %w", err)
+	}
+	markdown, err := htm.ConvertString(rendered.String())
+	if err != nil {
+		return nil, err
+	}
+
+	var sections []Section
+	current := Section{}
+	var body strings.Builder
+	flush := func() {
+		current.Markdown = strings.TrimSpace(body.String())
+		if current.Markdown != "" {
+			sections = append(sections, current)
+		}
+		body.Reset()
+	}
+
+	for _, line := range strings.Split(markdown, "\n") {
+		if m := sectionMarker.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			flush()
+			var i int
+			fmt.Sscanf(m[1], "%d", &i)
+			current = Section{Anchor: anchors[i], Heading: strings.TrimSpace(extractText(headings[i]))}
+			continue
+		}
+		body.WriteString(line)
+		body.WriteString("\n")
+	}
+	flush()
+
+	return sections, nil
+}
+
+// isHeading reports whether an element name is h1-h6.
+func isHeading(tag string) bool {
+	return len(tag) == 2 && tag[0] == 'h' && tag[1] >= '1' && tag[1] <= '6'
+}
+
+// headingAnchor returns the id of a heading, or of an anchor element inside it.
+func headingAnchor(n *html.Node) string {
+	for _, a := range n.Attr {
+		if a.Key == "id" && a.Val != "" {
+			return a.Val
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.Data != "a" {
+			continue
+		}
+		for _, a := range c.Attr {
+			if (a.Key == "id" || a.Key == "name") && a.Val != "" {
+				return a.Val
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,114 @@
+package scraper
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"path/filepath"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// pdfPageBreak separates the text of consecutive pages in ExtractPDFText's output.
+const pdfPageBreak = "\n\n---\n\n"
+
+// IsPDF reports whether a file is a PDF document, judging by its extension or, failing
+// that, by the "%PDF-" signature at the start of its content.
+//
+// Parameters:
+//   - name: The file name or path
+//   - data: The file content, or at least its first bytes
+//
+// Returns:
+//   - true if the file is a PDF document
+func IsPDF(name string, data []byte) bool {
+	return strings.EqualFold(filepath.Ext(name), ".pdf") || bytes.HasPrefix(data, []byte("%PDF-"))
+}
+
+// ExtractPDFText extracts the text of a PDF document.
+//
+// Pages are concatenated in order, separated by a "---" line so page boundaries survive
+// chunking. Pages without extractable text, such as scanned images, are skipped.
+//
+// Parameters:
+//   - data: The content of the PDF document
+//
+// Returns:
+//   - The document's text
+//   - An error if the document cannot be parsed or contains no text at all
+func ExtractPDFText(data []byte) (string, error) {
+	pages, err := extractPDFPages(data)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(pages, pdfPageBreak), nil
+}
+
+// extractPDFPages returns the trimmed text of every PDF page that has any.
+// The PDF reader panics on some malformed documents, so panics are turned into errors.
+func extractPDFPages(data []byte) (pages []string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			pages, err = nil, fmt.Errorf("failed to parse PDF: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse PDF: %w", err)
+	}
+
+	fonts := make(map[string]*pdf.Font)
+	for i := 1; i <= reader.NumPage(); i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		for _, name := range page.Fonts() {
+			if _, ok := fonts[name]; !ok {
+				font := page.Font(name)
+				fonts[name] = &font
+			}
+		}
+		text, err := page.GetPlainText(fonts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract text from PDF page %d: %w", i, err)
+		}
+		if text = strings.TrimSpace(text); text != "" {
+			pages = append(pages, text)
+		}
+	}
+
+	if len(pages) == 0 {
+		return nil, errors.New("PDF contains no extractable text")
+	}
+	return pages, nil
+}
+
+// pdfToHTML renders extracted PDF pages as a simple HTML page, so crawled PDFs go
+// through the same Markdown conversion as other pages. Blank-line separated blocks
+// become paragraphs and pages are separated by <hr>.
+func pdfToHTML(pages []string) string {
+	var b strings.Builder
+	b.WriteString("<html><body><main>")
+	for i, page := range pages {
+		if i > 0 {
+			b.WriteString("<hr>")
+		}
+		for _, paragraph := range strings.Split(page, "\n\n") {
+			var lines []string
+			for _, line := range strings.Split(paragraph, "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					lines = append(lines, html.EscapeString(line))
+				}
+			}
+			if len(lines) > 0 {
+				b.WriteString("<p>" + strings.Join(lines, "<br>") + "</p>")
+			}
+		}
+	}
+	b.WriteString("</main></body></html>")
+	return b.String()
+}
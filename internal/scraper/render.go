@@ -0,0 +1,53 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// RenderFunc fetches a page through an external renderer and returns its rendered HTML.
+type RenderFunc func(ctx context.Context, url string) (string, error)
+
+// RenderEndpoint returns a RenderFunc that asks an HTTP render service, such as Splash
+// or Browserless, for a page's rendered HTML. The endpoint is a URL template whose
+// "{url}" placeholder is replaced with the query-escaped page URL, for example
+// "http://localhost:8050/render.html?url={url}&wait=1".
+//
+// Parameters:
+//   - endpoint: The render service URL template
+//   - client: The HTTP client used to reach the service (or nil for http.DefaultClient)
+//
+// Returns:
+//   - A RenderFunc suitable for Config.RenderFunc
+func RenderEndpoint(endpoint string, client *http.Client) RenderFunc {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(ctx context.Context, pageURL string) (string, error) {
+		renderURL := strings.ReplaceAll(endpoint, "{url}", url.QueryEscape(pageURL))
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, renderURL, nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to create render request: %w", err)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("failed to render: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("render service returned status code: %d", resp.StatusCode)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return "", fmt.Errorf("failed to read rendered page: %w", err)
+		}
+		return string(body), nil
+	}
+}
@@ -0,0 +1,145 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+)
+
+// crawlResultVersion is the format version written by CrawlResult.Save.
+const crawlResultVersion = 1
+
+// CrawlResult is a snapshot of everything a crawl collected: the starting page's
+// metadata, the discovered paths and the per-path content.
+//
+// It is detached from the Scraper that produced it, so it can be kept after the
+// scraper is gone, saved to disk and loaded again to store the pages without
+// re-crawling, e.g. to retry a failed embedding run.
+type CrawlResult struct {
+	// Version is the format version, checked by LoadCrawlResult
+	Version int `json:"version"`
+	// URL is the URL the crawl started from
+	URL string `json:"url"`
+	// Metadata is the starting page's metadata
+	Metadata Metadata `json:"metadata"`
+	// Paths are all paths found during the crawl
+	Paths []string `json:"paths"`
+	// HTMLContent, MarkdownContent, Canonical, Language, Validators, NotModified, Depth
+	// and PDF mirror the Scraper's SubPaths* maps of the same names, PageURLs mirrors
+	// SubPathsURL and PathMetadata mirrors SubPathsMetadata
+	HTMLContent     map[string]string     `json:"html_content"`
+	MarkdownContent map[string]string     `json:"markdown_content"`
+	PageURLs        map[string]string     `json:"page_urls,omitempty"`
+	Canonical       map[string]string     `json:"canonical,omitempty"`
+	Language        map[string]string     `json:"language,omitempty"`
+	Validators      map[string]Validators `json:"validators,omitempty"`
+	NotModified     map[string]bool       `json:"not_modified,omitempty"`
+	Depth           map[string]int        `json:"depth"`
+	PDF             map[string]bool       `json:"pdf,omitempty"`
+	PathMetadata    map[string]Metadata   `json:"path_metadata,omitempty"`
+}
+
+// Result returns a snapshot of what the scraper has collected so far.
+//
+// The snapshot is taken under the scraper's mutex and copies every map, so it is safe
+// to call while a crawl is running and the result is unaffected by later crawling.
+//
+// Returns:
+//   - A CrawlResult holding copies of the scraper's metadata, paths and page content
+func (s *Scraper) Result() *CrawlResult {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return &CrawlResult{
+		Version:         crawlResultVersion,
+		URL:             s.URL,
+		Metadata:        s.Metadata,
+		Paths:           slices.Clone(s.SubPaths),
+		HTMLContent:     maps.Clone(s.SubPathsHTMLContent),
+		MarkdownContent: maps.Clone(s.SubPathsMarkdownContent),
+		PageURLs:        maps.Clone(s.SubPathsURL),
+		Canonical:       maps.Clone(s.SubPathsCanonical),
+		Language:        maps.Clone(s.SubPathsLanguage),
+		Validators:      maps.Clone(s.SubPathsValidators),
+		NotModified:     maps.Clone(s.SubPathsNotModified),
+		Depth:           maps.Clone(s.SubPathsDepth),
+		PDF:             maps.Clone(s.SubPathsPDF),
+		PathMetadata:    maps.Clone(s.SubPathsMetadata),
+	}
+}
+
+// Restore replaces the scraper's metadata, paths and page content with those of a
+// crawl result, as if the scraper had just performed that crawl.
+//
+// Parameters:
+//   - r: The crawl result to restore, typically from LoadCrawlResult
+func (s *Scraper) Restore(r *CrawlResult) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.Metadata = r.Metadata
+	s.SubPaths = slices.Clone(r.Paths)
+	s.SubPathsHTMLContent = cloneOrMake(r.HTMLContent)
+	s.SubPathsMarkdownContent = cloneOrMake(r.MarkdownContent)
+	s.SubPathsURL = cloneOrMake(r.PageURLs)
+	s.SubPathsCanonical = cloneOrMake(r.Canonical)
+	s.SubPathsLanguage = cloneOrMake(r.Language)
+	s.SubPathsValidators = cloneOrMake(r.Validators)
+	s.SubPathsNotModified = cloneOrMake(r.NotModified)
+	s.SubPathsDepth = cloneOrMake(r.Depth)
+	s.SubPathsPDF = cloneOrMake(r.PDF)
+	s.SubPathsMetadata = cloneOrMake(r.PathMetadata)
+}
+
+// cloneOrMake copies m, returning an empty map rather than nil when m is nil so the
+// result can be written to.
+func cloneOrMake[K comparable, V any](m map[K]V) map[K]V {
+	if m == nil {
+		return make(map[K]V)
+	}
+	return maps.Clone(m)
+}
+
+// Save writes the crawl result to a file as JSON.
+//
+// Parameters:
+//   - path: The file to write; it is created or truncated
+//
+// Returns:
+//   - An error if the result cannot be encoded or written
+func (r *CrawlResult) Save(path string) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to encode crawl result: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write crawl result: %w", err)
+	}
+	return nil
+}
+
+// LoadCrawlResult reads a crawl result written by CrawlResult.Save.
+//
+// Parameters:
+//   - path: The file to read
+//
+// Returns:
+//   - The crawl result
+//   - An error if the file cannot be read, is not a crawl result or has an unsupported version
+func LoadCrawlResult(path string) (*CrawlResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read crawl result: %w", err)
+	}
+
+	var r CrawlResult
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to decode crawl result: %w", err)
+	}
+	if r.Version != crawlResultVersion {
+		return nil, fmt.Errorf("unsupported crawl result version %d", r.Version)
+	}
+	return &r, nil
+}
@@ -7,13 +7,16 @@
 package scraper
 
 import (
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"net/url"
+	"os"
+	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -28,21 +31,118 @@ import (
 type Config struct {
 	// UserAgent is the User-Agent header value sent with HTTP requests
 	UserAgent string
+	// Headers are extra HTTP headers sent with every request, e.g. a gateway token
+	Headers map[string]string
+	// BasicAuthUser and BasicAuthPass enable HTTP basic authentication when BasicAuthUser is set
+	BasicAuthUser string
+	BasicAuthPass string
+	// Cookies are sent to the hosts they match, e.g. to crawl behind a login. A cookie's
+	// Domain also matches its subdomains; cookies without one go to the starting URL's host.
+	Cookies []*http.Cookie
+	// CookieFile is a Netscape-format cookie file whose cookies are sent like Cookies,
+	// host-only unless the file says they match subdomains
+	CookieFile string
 	// Timeout specifies the maximum duration to wait for an HTTP request to complete
 	Timeout time.Duration
+	// ProxyURL, when set, is the proxy every request goes through, e.g.
+	// "http://proxy.internal:3128". Otherwise the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	// environment variables are honored.
+	ProxyURL string
+	// Transport, when set, sends the scraper's requests instead of a copy of
+	// http.DefaultTransport, e.g. to customize TLS or connection pooling. ProxyURL is
+	// ignored then; configure the proxy on the transport itself.
+	Transport http.RoundTripper
 	// MaxDepth defines how deep the crawler will follow links from the starting URL
 	// A value of 0 means only the starting page, 1 means the starting page and all directly linked pages, etc.
 	MaxDepth int
+	// MaxPages caps the total number of pages fetched and stored during a crawl (0 means unlimited)
+	// It combines with MaxDepth: whichever limit is hit first ends the crawl
+	MaxPages int
 	// RequestDelay specifies the minimum time between requests to the same host
 	// This helps prevent overwhelming servers with too many rapid requests
 	RequestDelay time.Duration
 	// MaxConcurrent limits the total number of concurrent HTTP requests
 	// This applies across all hosts being scraped
 	MaxConcurrent int
+	// Adaptive lets the crawler tune its own concurrency between MinConcurrent and
+	// MaxConcurrent: it starts low, ramps up while pages load cleanly, and backs off
+	// (also stretching RequestDelay) when it sees 429s, 5xx responses or network errors
+	Adaptive bool
+	// MinConcurrent is the lower concurrency bound and starting point in adaptive mode
+	MinConcurrent int
+	// MaxRetries is how many times a page is re-requested after a network error, 429 or 5xx
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry; it doubles on every further attempt
+	RetryBackoff time.Duration
+	// FollowCrossHostRedirects lets pages redirect to another host. When false, such a
+	// redirect fails the page instead. When the starting page is redirected to another
+	// host, that host is the one the crawl stays on.
+	FollowCrossHostRedirects bool
+	// MaxContentBytes caps the size of a page's decoded body (0 means unlimited). Larger
+	// pages fail with ErrContentTooLarge instead of being read into memory.
+	MaxContentBytes int64
 	// Verbose enables verbose output with ASCII graphics
 	Verbose bool
+	// StopWhen is an optional predicate evaluated on every crawled page with its page key
+	// (path and query) and Markdown content. Once it returns true no further pages are fetched and the
+	// crawl returns what has been collected so far.
+	StopWhen func(path, content string) bool
+	// OnPage is an optional callback invoked after every crawled page is fetched, with
+	// the page URL, its crawl depth and the fetch error (nil on success). Calls are made
+	// from a single goroutine, one at a time. When set, per-page crawl errors are reported
+	// here instead of being printed.
+	OnPage func(url string, depth int, err error)
+	// IncludePatterns are regular expressions matched against a link's path. When set,
+	// only links whose path matches at least one pattern are crawled.
+	IncludePatterns []string
+	// StateFile, when set, is where the crawl saves its progress every few pages and when
+	// it ends, so an interrupted crawl can be continued with ResumeFrom
+	StateFile string
+	// ExcludePatterns are regular expressions matched against a link's path. Links whose
+	// path matches any pattern are never crawled. Exclusion takes precedence over inclusion.
+	ExcludePatterns []string
+	// RestrictToPathPrefix limits the crawl to URLs under the starting URL's path,
+	// e.g. only /docs/... when scraping https://example.com/docs/
+	RestrictToPathPrefix bool
+	// PathPrefix limits the crawl to URLs under an explicit path. It takes precedence
+	// over the prefix derived from RestrictToPathPrefix.
+	PathPrefix string
+	// StripQueryParams are query parameters dropped when normalizing discovered URLs,
+	// so tracking variants of a page are crawled once. A trailing "*" matches by prefix.
+	StripQueryParams []string
+	// StripSelectors pick out boilerplate such as menus, sidebars, cookie banners and
+	// footers that is removed before text extraction and before crawled pages are converted
+	// to Markdown, so it is never stored. A selector is a tag name ("nav"),
+	// a class fragment (".sidebar"), an id ("#menu") or an attribute value ("[role=banner]").
+	StripSelectors []string
+	// LinkElements are the elements whose href is followed when crawling: "a", "area" and
+	// "link" (only <link rel="next"> and <link rel="prev">). Empty means DefaultLinkElements.
+	LinkElements []string
+	// MetadataOnly crawls as usual but only records each page's metadata in
+	// SubPathsMetadata: pages are not converted to Markdown, and SubPathsHTMLContent and
+	// SubPathsMarkdownContent stay empty. StopWhen is called with empty content.
+	MetadataOnly bool
+	// PriorValidators, when set, returns the validators saved from an earlier crawl of a
+	// page URL. Pages with validators are requested conditionally; a 304 Not Modified
+	// marks the page in SubPathsNotModified instead of storing its content again.
+	PriorValidators func(pageURL string) Validators
+	// RenderFunc, when set, fetches pages instead of the built-in HTTP client, e.g. to
+	// get JavaScript-rendered HTML from a headless browser service. Rate limiting still
+	// applies; retries, headers and conditional requests are left to the renderer.
+	RenderFunc RenderFunc
+	// ChunkSize is the maximum length in characters of the chunks long pages are split
+	// into for embedding (0 disables chunking); see ChunkMarkdown
+	ChunkSize int
+	// ChunkOverlap is how many characters consecutive chunks share
+	ChunkOverlap int
 }
 
+// DefaultUserAgent is the default Config.UserAgent.
+const DefaultUserAgent = "Mozilla/5.0 (compatible; PonsScraper/1.0)"
+
+// DefaultMaxContentBytes is the default Config.MaxContentBytes: 10 MB.
+const DefaultMaxContentBytes = 10 << 20
+
 // DefaultConfig returns a default configuration with reasonable values.
 //
 // The default configuration includes a standard user agent, reasonable timeout,
@@ -52,12 +152,21 @@ type Config struct {
 //   - A Config struct with default values
 func DefaultConfig() *Config {
 	return &Config{
-		UserAgent:     "Mozilla/5.0 (compatible; PonsScraper/1.0)",
-		Timeout:       10 * time.Second,
-		MaxDepth:      5,
-		RequestDelay:  1 * time.Second,
-		MaxConcurrent: 2,
-		Verbose:       false,
+		UserAgent:                DefaultUserAgent,
+		Timeout:                  10 * time.Second,
+		MaxDepth:                 5,
+		RequestDelay:             1 * time.Second,
+		MaxConcurrent:            2,
+		MaxRetries:               2,
+		RetryBackoff:             1 * time.Second,
+		MaxContentBytes:          DefaultMaxContentBytes,
+		FollowCrossHostRedirects: true,
+		Verbose:                  false,
+		StripQueryParams:         DefaultStripQueryParams,
+		StripSelectors:           DefaultStripSelectors,
+		LinkElements:             DefaultLinkElements,
+		ChunkSize:                2000,
+		ChunkOverlap:             200,
 	}
 }
 
@@ -69,6 +178,8 @@ type Metadata struct {
 	Title string
 	// Description is the content of the meta description tag
 	Description string
+	// Canonical is the absolute URL declared by <link rel="canonical">, if any
+	Canonical string
 }
 
 // Scraper is responsible for scraping web content.
@@ -82,7 +193,8 @@ type Scraper struct {
 	Metadata Metadata
 	// Content holds the parsed HTML content
 	Content *html.Node
-	// SubPaths contains all discovered paths during crawling
+	// SubPaths contains all pages discovered during crawling, as their path followed by
+	// their query, if any. The SubPaths* maps are keyed the same way; see PageURL.
 	SubPaths []string
 	// Config contains all the configuration options for this scraper
 	Config *Config
@@ -99,8 +211,39 @@ type Scraper struct {
 	SubPathsHTMLContent map[string]string
 	// SubPathsMarkdownContent stores the Markdown content of each subpath
 	SubPathsMarkdownContent map[string]string
+	// SubPathsURL stores the absolute URL each subpath was served from after redirects,
+	// which is on another host than URL once the crawl followed a cross-host redirect
+	SubPathsURL map[string]string
+	// SubPathsCanonical stores the canonical URL declared by each subpath, when present
+	SubPathsCanonical map[string]string
+	// SubPathsLanguage stores the primary language subtag of each subpath, as declared by
+	// <html lang> or else detected from its text, when known
+	SubPathsLanguage map[string]string
+	// SubPathsValidators stores the ETag/Last-Modified validators sent with each subpath, when present
+	SubPathsValidators map[string]Validators
+	// SubPathsNotModified marks subpaths that a conditional request found unchanged.
+	// These pages have no entry in SubPathsHTMLContent or SubPathsMarkdownContent.
+	SubPathsNotModified map[string]bool
+	// SubPathsDepth stores the crawl depth at which each subpath was found (0 for the starting URL)
+	SubPathsDepth map[string]int
+	// SubPathsPDF marks subpaths served as PDF documents. Their text is stored in
+	// SubPathsHTMLContent as a simple HTML page, one paragraph per block of text.
+	SubPathsPDF map[string]bool
+	// SubPathsMetadata stores the title, description and canonical URL of each subpath
+	SubPathsMetadata map[string]Metadata
 	// Verbose enables verbose output
 	Verbose bool
+	// Logger receives the scraper's progress output and crawl errors. New sets it to
+	// write to stdout when Config.Verbose is set and to discard everything otherwise;
+	// replace it to redirect or silence the output.
+	Logger *log.Logger
+	// tuner adapts concurrency and request delay when Config.Adaptive is set
+	tuner *adaptiveTuner
+	// includePatterns and excludePatterns are the compiled Config path filters
+	includePatterns []*regexp.Regexp
+	excludePatterns []*regexp.Regexp
+	// pathPrefix is the resolved PathPrefix/RestrictToPathPrefix restriction, "" for none
+	pathPrefix string
 }
 
 // New creates a new scraper with the given URL and configuration.
@@ -115,13 +258,39 @@ type Scraper struct {
 //
 // Returns:
 //   - A new Scraper instance ready to use
-func New(url string, config *Config) *Scraper {
+//   - An error if the configuration is invalid, such as a malformed path pattern
+func New(url string, config *Config) (*Scraper, error) {
 	if config == nil {
 		config = DefaultConfig()
 	}
 
+	includePatterns, err := compilePatterns(config.IncludePatterns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid include pattern: %w", err)
+	}
+	excludePatterns, err := compilePatterns(config.ExcludePatterns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exclude pattern: %w", err)
+	}
+
+	pathPrefix, err := resolvePathPrefix(url, config)
+	if err != nil {
+		return nil, err
+	}
+
+	transport, err := newTransport(config)
+	if err != nil {
+		return nil, err
+	}
+	jar, err := newCookieJar(config, url)
+	if err != nil {
+		return nil, err
+	}
 	client := &http.Client{
-		Timeout: config.Timeout,
+		Transport:     transport,
+		Timeout:       config.Timeout,
+		CheckRedirect: redirectPolicy(config),
+		Jar:           jar,
 	}
 
 	s := &Scraper{
@@ -132,39 +301,127 @@ func New(url string, config *Config) *Scraper {
 		requestSem:              make(chan struct{}, config.MaxConcurrent),
 		SubPathsHTMLContent:     make(map[string]string),
 		SubPathsMarkdownContent: make(map[string]string),
+		SubPathsURL:             make(map[string]string),
+		SubPathsCanonical:       make(map[string]string),
+		SubPathsLanguage:        make(map[string]string),
+		SubPathsValidators:      make(map[string]Validators),
+		SubPathsNotModified:     make(map[string]bool),
+		SubPathsDepth:           make(map[string]int),
+		SubPathsPDF:             make(map[string]bool),
+		SubPathsMetadata:        make(map[string]Metadata),
 		Verbose:                 config.Verbose,
+		Logger:                  log.New(io.Discard, "", 0),
+		includePatterns:         includePatterns,
+		excludePatterns:         excludePatterns,
+		pathPrefix:              strings.TrimSuffix(pathPrefix, "/"),
+	}
+
+	if config.Verbose {
+		s.Logger = log.New(os.Stdout, "", 0)
+	}
+	if config.Adaptive {
+		s.tuner = newAdaptiveTuner(config.MinConcurrent, config.MaxConcurrent)
 	}
 
 	s.displayInitBanner()
 
-	return s
+	return s, nil
+}
+
+// compilePatterns compiles a list of regular expressions.
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
 }
 
-// waitForRateLimit waits for rate limiting based on the host
-func (s *Scraper) waitForRateLimit(host string) {
+// resolvePathPrefix returns the path prefix the crawl is restricted to, if any.
+func resolvePathPrefix(rawURL string, config *Config) (string, error) {
+	if config.PathPrefix != "" || !config.RestrictToPathPrefix {
+		return config.PathPrefix, nil
+	}
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL: %w", err)
+	}
+	return parsedURL.Path, nil
+}
+
+// linkElements returns the elements whose links are followed, falling back to DefaultLinkElements.
+func (s *Scraper) linkElements() []string {
+	if len(s.Config.LinkElements) == 0 {
+		return DefaultLinkElements
+	}
+	return s.Config.LinkElements
+}
+
+// allowedPath reports whether a path passes the path prefix and the include and exclude filters.
+// Exclude patterns take precedence over include patterns.
+func (s *Scraper) allowedPath(path string) bool {
+	// Match whole segments so a /docs prefix allows /docs/x but not /docsearch
+	if s.pathPrefix != "" && path != s.pathPrefix && !strings.HasPrefix(path, s.pathPrefix+"/") {
+		return false
+	}
+	for _, re := range s.excludePatterns {
+		if re.MatchString(path) {
+			return false
+		}
+	}
+	if len(s.includePatterns) == 0 {
+		return true
+	}
+	for _, re := range s.includePatterns {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// waitForRateLimit waits for rate limiting based on the host.
+// On success the caller holds a semaphore slot and must release it; if ctx is
+// cancelled first, no slot is held and ctx.Err() is returned.
+func (s *Scraper) waitForRateLimit(ctx context.Context, host string) error {
 	// Acquire semaphore slot (limits concurrent requests)
-	s.requestSem <- struct{}{}
+	select {
+	case s.requestSem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 
-	// Check and enforce per-host rate limiting
+	// Check and enforce per-host rate limiting. Each caller reserves the next
+	// free slot for the host before sleeping, so concurrent workers hitting the
+	// same host are still spaced at least RequestDelay apart.
 	s.mutex.Lock()
-	lastReq, exists := s.lastRequestTime[host]
-	now := time.Now()
-
-	if exists {
-		// Calculate time since last request
-		elapsed := now.Sub(lastReq)
-
-		// If not enough time has passed, sleep for the remaining duration
-		if elapsed < s.Config.RequestDelay {
-			s.mutex.Unlock()
-			time.Sleep(s.Config.RequestDelay - elapsed)
-			s.mutex.Lock()
+	delay := s.Config.RequestDelay
+	if s.tuner != nil {
+		delay = s.tuner.delay(delay)
+	}
+	next := time.Now()
+	if lastReq, exists := s.lastRequestTime[host]; exists {
+		if earliest := lastReq.Add(delay); earliest.After(next) {
+			next = earliest
 		}
 	}
-
-	// Update last request time
-	s.lastRequestTime[host] = time.Now()
+	s.lastRequestTime[host] = next
 	s.mutex.Unlock()
+
+	// If not enough time has passed, sleep for the remaining duration
+	timer := time.NewTimer(time.Until(next))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		<-s.requestSem
+		return ctx.Err()
+	}
 }
 
 // GetContent fetches the content of the URL and parses it.
@@ -176,17 +433,60 @@ func (s *Scraper) waitForRateLimit(host string) {
 //   - An error if the content cannot be fetched or parsed, nil otherwise
 func (s *Scraper) GetContent() error {
 	done := s.startSpinner("Fetching " + s.URL)
-	doc, _, err := s.fetchURL(s.URL)
+	page, err := s.fetchURL(context.Background(), s.URL, Validators{})
+	close(done)
 	if err != nil {
 		s.displayError(err)
 		return fmt.Errorf("failed to fetch content: %w", err)
 	}
-	close(done)
 
-	s.Content = doc
+	s.Content = page.doc
 	return nil
 }
 
+// PageURL returns the absolute URL of a crawled page from its key in SubPaths: the URL
+// it was served from, as recorded in SubPathsURL. Pages without a recorded URL, such as
+// those of crawl results saved before it was kept, are resolved against the URL the
+// crawl started from.
+//
+// Parameters:
+//   - key: A page key from SubPaths: a path, followed by a query if the page has one
+//
+// Returns:
+//   - The page's URL, including its query
+func (s *Scraper) PageURL(key string) string {
+	if pageURL := s.SubPathsURL[key]; pageURL != "" {
+		return pageURL
+	}
+	path, query, _ := strings.Cut(key, "?")
+	base, err := url.Parse(s.URL)
+	if err != nil {
+		return s.URL + key
+	}
+	return base.ResolveReference(&url.URL{Path: path, RawQuery: query}).String()
+}
+
+// PageMetadata returns the title and description of a crawled page, falling back to
+// those of the starting page when the page doesn't declare them.
+//
+// Parameters:
+//   - key: A page key from SubPaths
+//
+// Returns:
+//   - The page's metadata, with surrounding whitespace trimmed
+func (s *Scraper) PageMetadata(key string) Metadata {
+	metadata := s.SubPathsMetadata[key]
+	metadata.Title = strings.TrimSpace(metadata.Title)
+	metadata.Description = strings.TrimSpace(metadata.Description)
+	if metadata.Title == "" {
+		metadata.Title = strings.TrimSpace(s.Metadata.Title)
+	}
+	if metadata.Description == "" {
+		metadata.Description = strings.TrimSpace(s.Metadata.Description)
+	}
+	return metadata
+}
+
 // GetSubPathHTMLContent returns the HTML content of a subpath.
 func (s *Scraper) GetSubPathHTMLContent() map[string]string {
 	return s.SubPathsHTMLContent
@@ -242,7 +542,71 @@ func extractDescription(n *html.Node) string {
 	return ""
 }
 
-// GetMetadata extracts metadata (title, description) from the HTML content.
+// extractCanonical extracts the <link rel="canonical"> href from an HTML node,
+// resolved against pageURL
+func extractCanonical(n *html.Node, pageURL *url.URL) string {
+	if n.Type == html.ElementNode && n.Data == "link" {
+		var isCanonical bool
+		var href string
+
+		for _, a := range n.Attr {
+			if a.Key == "rel" && strings.EqualFold(strings.TrimSpace(a.Val), "canonical") {
+				isCanonical = true
+			}
+			if a.Key == "href" {
+				href = strings.TrimSpace(a.Val)
+			}
+		}
+
+		if isCanonical && href != "" {
+			parsed, err := url.Parse(href)
+			if err != nil {
+				return ""
+			}
+			return pageURL.ResolveReference(parsed).String()
+		}
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if canonical := extractCanonical(c, pageURL); canonical != "" {
+			return canonical
+		}
+	}
+
+	return ""
+}
+
+// extractLanguage returns the primary language subtag of the <html lang> attribute,
+// lowercased, e.g. "en" for lang="en-US"
+func extractLanguage(n *html.Node) string {
+	if n.Type == html.ElementNode && n.Data == "html" {
+		for _, a := range n.Attr {
+			if a.Key == "lang" {
+				return NormalizeLanguage(a.Val)
+			}
+		}
+		return ""
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if language := extractLanguage(c); language != "" {
+			return language
+		}
+	}
+
+	return ""
+}
+
+// NormalizeLanguage reduces a language tag such as "en-US" or "EN_gb" to its lowercase primary subtag.
+func NormalizeLanguage(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	return tag
+}
+
+// GetMetadata extracts metadata (title, description, canonical URL) from the HTML content.
 //
 // This method extracts the title and description from the HTML content.
 // It requires that GetContent has been called first to populate the Content field.
@@ -261,6 +625,9 @@ func (s *Scraper) GetMetadata() error {
 	// Store extracted metadata
 	s.Metadata.Title = title
 	s.Metadata.Description = description
+	if baseURL, err := url.Parse(s.URL); err == nil {
+		s.Metadata.Canonical = extractCanonical(s.Content, baseURL)
+	}
 
 	if s.Verbose {
 		s.displayMetadata()
@@ -271,19 +638,32 @@ func (s *Scraper) GetMetadata() error {
 
 // GetAllPaths crawls the website and collects all paths.
 //
-// This method performs a depth-first crawl of the website starting from the base URL.
-// It respects the MaxDepth configuration and only follows links within the same host.
-// The method stores all discovered paths in the SubPaths field.
+// This is equivalent to CrawlWithContext with context.Background().
 //
 // Returns:
 //   - An error if the crawling fails, nil otherwise
 func (s *Scraper) GetAllPaths() error {
+	return s.CrawlWithContext(context.Background())
+}
+
+// CrawlWithContext crawls the website and collects all paths until done or ctx is cancelled.
+//
+// This method performs a concurrent breadth-first crawl of the website starting from the base URL.
+// It respects the MaxDepth configuration and only follows links within the same host.
+// The method stores all discovered paths in the SubPaths field. If ctx is cancelled or its
+// deadline passes, in-flight requests are aborted, SubPaths holds the pages fetched so far,
+// and ctx.Err() is returned.
+//
+// Parameters:
+//   - ctx: Controls cancellation and deadline of the whole crawl
+//
+// Returns:
+//   - An error if the crawling fails or is cancelled, nil otherwise
+func (s *Scraper) CrawlWithContext(ctx context.Context) error {
 	s.displayCrawlStartBanner()
 	parsedBase, err := url.Parse(s.URL)
 	if err != nil {
-		if s.Verbose {
-			s.displayError(err)
-		}
+		s.displayError(err)
 		return fmt.Errorf("invalid base URL: %w", err)
 	}
 
@@ -291,14 +671,9 @@ func (s *Scraper) GetAllPaths() error {
 	visited := make(map[string]bool)
 	paths := make(map[string]bool)
 
-	// Start crawling from the base URL
-	err = s.Crawl(parsedBase, parsedBase, paths, visited, 0)
-	if err != nil {
-		if s.Verbose {
-			s.displayError(err)
-		}
-		return fmt.Errorf("crawling failed: %w", err)
-	}
+	// Start crawling from the base URL; only its canonical host is compared against links
+	start := withoutFragment(parsedBase)
+	err = s.Crawl(ctx, normalizeURL(parsedBase, s.Config.StripQueryParams), start, paths, visited, 0)
 
 	// Convert paths map to slice for easier access
 	pathSlice := make([]string, 0, len(paths))
@@ -307,6 +682,13 @@ func (s *Scraper) GetAllPaths() error {
 	}
 
 	s.SubPaths = pathSlice
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		s.displayError(err)
+		return fmt.Errorf("crawling failed: %w", err)
+	}
 	if s.Verbose {
 		s.displayCrawlEndBanner()
 		s.displaySubpathResults()
@@ -314,84 +696,79 @@ func (s *Scraper) GetAllPaths() error {
 	return nil
 }
 
-// fetchURL fetches the content of a URL and returns the HTML document and its string representation
-func (s *Scraper) fetchURL(urlStr string) (*html.Node, string, error) {
-	// Parse URL to get host for rate limiting
-	parsedURL, err := url.Parse(urlStr)
+// ScrapeSinglePage fetches only the scraper's URL, without following any of its links.
+//
+// The page is recorded in SubPaths and the SubPaths* maps exactly as a crawl with a
+// MaxDepth of 0 would, so it can be stored like crawled pages. Config.OnPage is called
+// once with the outcome.
+//
+// Returns:
+//   - The page's path, followed by its query if it has one
+//   - The page's Markdown content, empty when a conditional request found it unchanged
+//   - An error if the page cannot be fetched or converted
+func (s *Scraper) ScrapeSinglePage() (path, content string, err error) {
+	pageURL, err := url.Parse(s.URL)
 	if err != nil {
-		return nil, "", fmt.Errorf("invalid URL: %w", err)
+		return "", "", fmt.Errorf("invalid base URL: %w", err)
 	}
+	pageURL = withoutFragment(pageURL)
 
-	// Apply rate limiting based on host
-	s.waitForRateLimit(parsedURL.Host)
-	defer func() { <-s.requestSem }() // Release semaphore when done
-
-	// Create a request with context and user agent
-	ctx, cancel := context.WithTimeout(context.Background(), s.Config.Timeout)
-	defer cancel()
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlStr, nil)
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	_, _, err = s.crawlPage(context.Background(), crawlTask{url: pageURL}, make(map[string]bool))
+	if s.Config.OnPage != nil {
+		s.Config.OnPage(pageURL.String(), 0, err)
 	}
-
-	req.Header.Set("User-Agent", s.Config.UserAgent)
-
-	// Make HTTP GET request
-	resp, err := s.client.Do(req)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to fetch: %w", err)
+		return "", "", err
 	}
-	defer resp.Body.Close()
 
-	// Check response status code
-	if resp.StatusCode != http.StatusOK {
-		return nil, "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
-	// Check if response is HTML
-	contentType := resp.Header.Get("Content-Type")
-	if !strings.Contains(contentType, "text/html") {
-		return nil, "", fmt.Errorf("not HTML content: %s", contentType)
-	}
+	// crawlPage records the page under its path and query, "/" for the bare host
+	path = pageKey(pageURL)
+	s.SubPaths = []string{path}
+	return path, s.SubPathsMarkdownContent[path], nil
+}
 
-	// Read body
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to read body: %w", err)
-	}
+// DefaultLinkElements lists the elements whose links are followed during a crawl.
+// See Config.LinkElements.
+var DefaultLinkElements = []string{"a", "area", "link"}
 
-	// Parse HTML
-	doc, err := html.Parse(bytes.NewReader(bodyBytes))
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to parse HTML: %w", err)
-	}
+// followedLinkRels are the <link rel> values followed during a crawl: rel-based pagination.
+var followedLinkRels = []string{"next", "prev"}
 
-	return doc, string(bodyBytes), nil
-}
-
-// extractLinks extracts all links from an HTML document
-func extractLinks(doc *html.Node, baseURL *url.URL, visited map[string]bool) []*url.URL {
+// extractLinks extracts all links on host, a canonical host, from an HTML document served
+// from pageURL. Only the href of the given elements is considered, and a <link> only when
+// its rel is one of followedLinkRels. Links are returned as resolved, without their
+// fragment; the visited check uses their canonical urlKey.
+func extractLinks(doc *html.Node, pageURL *url.URL, host string, visited map[string]bool, stripParams, elements []string) []*url.URL {
 	var links []*url.URL
 
 	var extract func(*html.Node)
 	extract = func(n *html.Node) {
-		if n.Type == html.ElementNode && n.Data == "a" {
+		if n.Type == html.ElementNode && slices.Contains(elements, n.Data) && (n.Data != "link" || isFollowedLinkRel(n)) {
 			for _, attr := range n.Attr {
 				if attr.Key == "href" {
 					link := attr.Val
 
 					// Parse the link
-					parsedLink, err := url.Parse(link)
+					parsedLink, err := url.Parse(strings.TrimSpace(link))
 					if err != nil {
 						continue // Skip invalid URLs
 					}
 
-					// Resolve relative URLs
-					parsedLink = baseURL.ResolveReference(parsedLink)
+					// Skip javascript:, mailto:, tel:, data: and other non-web links
+					if parsedLink.Scheme != "" && !isHTTPScheme(parsedLink.Scheme) {
+						continue
+					}
+
+					// Resolve relative and protocol-relative (//host/path) URLs
+					// against the page
+					parsedLink = withoutFragment(pageURL.ResolveReference(parsedLink))
+					if !isHTTPScheme(parsedLink.Scheme) {
+						continue
+					}
 
 					// Only include links within the same host and not yet visited
-					if parsedLink.Host == baseURL.Host && !visited[parsedLink.String()] {
+					canonical := normalizeURL(parsedLink, stripParams)
+					if canonical.Host == host && !visited[canonical.String()] {
 						links = append(links, parsedLink)
 					}
 				}
@@ -408,69 +785,292 @@ func extractLinks(doc *html.Node, baseURL *url.URL, visited map[string]bool) []*
 	return links
 }
 
-// Crawl recursively crawls a website starting from the given URL.
+// isFollowedLinkRel reports whether a <link> element's rel, a space-separated list,
+// contains one of followedLinkRels.
+func isFollowedLinkRel(n *html.Node) bool {
+	for _, attr := range n.Attr {
+		if attr.Key != "rel" {
+			continue
+		}
+		for _, rel := range strings.Fields(strings.ToLower(attr.Val)) {
+			if slices.Contains(followedLinkRels, rel) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// crawlTask is a single URL waiting in the crawl frontier.
+type crawlTask struct {
+	url   *url.URL
+	depth int
+}
+
+// crawlResult is reported by a crawl worker once it has processed a task.
+type crawlResult struct {
+	task crawlTask
+	page *fetchedPage
+	stop bool
+	err  error
+}
+
+// Crawl crawls a website breadth-first starting from the given URL.
 //
-// This method implements a depth-first crawl of the website, following links
-// within the same host up to the configured maximum depth. It respects rate
-// limiting settings and tracks visited URLs to avoid cycles.
+// This method walks the website level by level using a pool of Config.MaxConcurrent
+// workers, following links within the same host up to the configured maximum depth.
+// Every fetch still goes through the per-host rate limiter, and visited URLs are
+// tracked to avoid cycles. Shared crawl state is protected by the scraper's mutex.
 //
 // Parameters:
+//   - ctx: Cancels the crawl; in-flight workers return promptly once it is done
 //   - baseURL: The original base URL of the website
-//   - currentURL: The current URL being crawled
+//   - currentURL: The URL to start crawling from
 //   - paths: A map to collect all unique paths found
 //   - visited: A map of already visited URLs to avoid duplicates
-//   - depth: The current crawl depth (0 for the starting URL)
+//   - depth: The crawl depth of currentURL (0 for the starting URL)
 //
 // Returns:
-//   - An error if the crawling fails catastrophically (individual page errors are logged but don't stop the crawl)
-func (s *Scraper) Crawl(baseURL, currentURL *url.URL, paths, visited map[string]bool, depth int) error {
+//   - An error if the starting URL cannot be crawled (errors on other pages are logged but don't stop the crawl)
+func (s *Scraper) Crawl(ctx context.Context, baseURL, currentURL *url.URL, paths, visited map[string]bool, depth int) error {
 	// Check if we've reached the maximum crawl depth
 	if depth > s.Config.MaxDepth {
 		return nil
 	}
 
 	// Skip if already visited
-	urlStr := currentURL.String()
-	if visited[urlStr] {
+	key := urlKey(currentURL, s.Config.StripQueryParams)
+	if visited[key] {
 		return nil
 	}
-	visited[urlStr] = true
+	visited[key] = true
+
+	return s.crawl(ctx, baseURL, currentURL, []crawlTask{{url: currentURL, depth: depth}}, paths, visited)
+}
+
+// crawl runs the crawl loop from an initial frontier. start is the crawl's starting
+// URL, or nil when resuming; its fetch error is returned and a redirect of it to
+// another host moves the crawl to that host. When Config.StateFile is set, progress is
+// saved every stateSaveEvery pages and once the loop ends.
+func (s *Scraper) crawl(ctx context.Context, baseURL, start *url.URL, frontier []crawlTask, paths, visited map[string]bool) error {
+	workers := s.Config.MaxConcurrent
+	if workers < 1 {
+		workers = 1
+	}
+
+	tasks := make(chan crawlTask)
+	results := make(chan crawlResult)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range tasks {
+				page, stop, err := s.crawlPage(ctx, task, paths)
+				results <- crawlResult{task: task, page: page, stop: stop, err: err}
+			}
+		}()
+	}
+
+	// The coordinator owns the frontier and the visited map; workers only fetch.
+	// Pages that are in flight or were cut short by cancellation are kept for the state file.
+	inFlight := make(map[crawlTask]bool)
+	var interrupted []crawlTask
+	stored := len(paths)
+	stopped := false
+	cancelled := ctx.Done()
+	var startErr, stateErr error
+
+	for len(frontier) > 0 || len(inFlight) > 0 {
+		// Only offer the next task when there is one and the page budget allows it;
+		// a nil channel never sends.
+		var sendTasks chan crawlTask
+		var next crawlTask
+		if len(frontier) > 0 && (s.Config.MaxPages <= 0 || stored+len(inFlight) < s.Config.MaxPages) {
+			sendTasks = tasks
+			next = frontier[0]
+		}
+
+		select {
+		case <-cancelled:
+			// Stop handing out work and wait for in-flight workers to bail out
+			cancelled = nil
+			stopped = true
+			interrupted = append(interrupted, frontier...)
+			frontier = nil
+		case sendTasks <- next:
+			frontier = frontier[1:]
+			inFlight[next] = true
+		case res := <-results:
+			delete(inFlight, res.task)
+			if s.Config.OnPage != nil {
+				s.Config.OnPage(res.task.url.String(), res.task.depth, res.err)
+			}
+			if res.err != nil {
+				// Report the error but continue crawling other links
+				if s.Config.OnPage == nil {
+					s.displayError(res.err)
+				}
+				if res.task.url == start {
+					startErr = res.err
+				}
+				if ctx.Err() != nil {
+					interrupted = append(interrupted, res.task)
+				}
+				continue
+			}
+			stored++
+			if s.Config.StateFile != "" && stored%stateSaveEvery == 0 {
+				if err := s.saveState(baseURL, pendingTasks(frontier, inFlight), visited, paths); err != nil && stateErr == nil {
+					stateErr = err
+				}
+			}
+			if res.stop || (s.Config.MaxPages > 0 && stored >= s.Config.MaxPages) {
+				// StopWhen matched or the page budget is spent: drop the frontier
+				// and let in-flight pages finish
+				stopped = true
+				frontier = nil
+			}
+			if stopped || res.task.depth+1 > s.Config.MaxDepth || res.page == nil {
+				continue
+			}
+
+			// Links are resolved against the URL the page was actually served from. When
+			// the starting page redirects to another host, the crawl moves to that host.
+			pageURL := res.task.url
+			if res.page.finalURL != nil {
+				pageURL = res.page.finalURL
+				visited[urlKey(pageURL, s.Config.StripQueryParams)] = true
+			}
+			if canonical := normalizeURL(pageURL, s.Config.StripQueryParams); res.task.url == start && canonical.Host != baseURL.Host {
+				baseURL = &url.URL{Scheme: canonical.Scheme, Host: canonical.Host}
+			}
+			for _, link := range extractLinks(res.page.doc, pageURL, baseURL.Host, visited, s.Config.StripQueryParams, s.linkElements()) {
+				key := urlKey(link, s.Config.StripQueryParams)
+				if visited[key] || !s.allowedPath(link.Path) {
+					continue
+				}
+				visited[key] = true
+				frontier = append(frontier, crawlTask{url: link, depth: res.task.depth + 1})
+			}
+		}
+	}
+
+	close(tasks)
+	wg.Wait()
+
+	if s.Config.StateFile != "" {
+		if err := s.saveState(baseURL, interrupted, visited, paths); err != nil && stateErr == nil {
+			stateErr = err
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if startErr == nil {
+		// The pages were crawled, but the crawl could not be made resumable
+		return stateErr
+	}
+	return startErr
+}
+
+// pendingTasks returns the frontier followed by the tasks in flight, in no particular order.
+func pendingTasks(frontier []crawlTask, inFlight map[crawlTask]bool) []crawlTask {
+	pending := append([]crawlTask(nil), frontier...)
+	for task := range inFlight {
+		pending = append(pending, task)
+	}
+	return pending
+}
+
+// crawlPage fetches a single page and records its path and content.
+// It reports whether Config.StopWhen matched the page.
+func (s *Scraper) crawlPage(ctx context.Context, task crawlTask, paths map[string]bool) (*fetchedPage, bool, error) {
+	urlStr := task.url.String()
+
+	// Pages are recorded by path and query
+	path := pageKey(task.url)
+
+	var prior Validators
+	if s.Config.PriorValidators != nil {
+		prior = s.Config.PriorValidators(urlStr)
+	}
 
 	// Fetch and parse the URL
 	done := s.startSpinner("Crawling " + urlStr)
-	doc, htmlContent, err := s.fetchURL(urlStr)
-	if err != nil {
-		s.displayError(err)
-		return fmt.Errorf("failed to fetch %s: %w", urlStr, err)
+	page, err := s.fetchURL(ctx, urlStr, prior)
+	if errors.Is(err, ErrNotModified) {
+		s.mutex.Lock()
+		paths[path] = true
+		s.SubPathsNotModified[path] = true
+		s.mutex.Unlock()
+
+		// The page itself is unchanged, but its links are still needed unless
+		// the crawl won't go any deeper
+		if task.depth >= s.Config.MaxDepth {
+			close(done)
+			return nil, false, nil
+		}
+		page, err = s.fetchURL(ctx, urlStr, Validators{})
+		close(done)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to fetch %s: %w", urlStr, err)
+		}
+		return page, false, nil
 	}
 	close(done)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to fetch %s: %w", urlStr, err)
+	}
+
+	// parse to markdown
+	var markdown string
+	if !s.Config.MetadataOnly {
+		parser := Parser{StripSelectors: s.Config.StripSelectors}
+		markdown, err = parser.ToMarkdown(page.body)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to convert %s to markdown: %w", urlStr, err)
+		}
+	}
 
-	// Extract path from current URL
-	path := currentURL.Path
-	if path == "" {
-		path = "/"
+	metadata := Metadata{
+		Title:       extractTitle(page.doc),
+		Description: extractDescription(page.doc),
+		Canonical:   extractCanonical(page.doc, page.finalURL),
 	}
+
+	s.mutex.Lock()
 	paths[path] = true
-	s.SubPathsHTMLContent[path] = htmlContent
-	// parse to markdown
-	var parser Parser
-	markdown, err := parser.ToMarkdown(htmlContent)
-	if err != nil {
-		return fmt.Errorf("failed to convert to markdown: %w", err)
-	} else {
+	s.SubPathsDepth[path] = task.depth
+	s.SubPathsMetadata[path] = metadata
+	s.SubPathsURL[path] = withoutFragment(page.finalURL).String()
+	if !s.Config.MetadataOnly {
+		s.SubPathsHTMLContent[path] = page.body
 		s.SubPathsMarkdownContent[path] = markdown
 	}
-
-	// Extract and process links
-	links := extractLinks(doc, baseURL, visited)
-	for _, link := range links {
-		if err := s.Crawl(baseURL, link, paths, visited, depth+1); err != nil {
-			// Log error but continue crawling other links
-			s.displayError(err)
-		}
+	if page.pdf {
+		s.SubPathsPDF[path] = true
+	}
+	if metadata.Canonical != "" {
+		s.SubPathsCanonical[path] = metadata.Canonical
 	}
+	// Fall back to guessing from the text for pages that don't declare a language
+	language := extractLanguage(page.doc)
+	if language == "" && markdown != "" {
+		language = DetectLanguage(markdown)
+	}
+	if language != "" {
+		s.SubPathsLanguage[path] = language
+	}
+	if page.validators != (Validators{}) {
+		s.SubPathsValidators[path] = page.validators
+	}
+	s.mutex.Unlock()
 
-	return nil
+	stop := s.Config.StopWhen != nil && s.Config.StopWhen(path, markdown)
+	return page, stop, nil
 }
 
 // ScrapeContent fetches the URL and scrapes the main content.
@@ -487,7 +1087,8 @@ func (s *Scraper) ScrapeContent() (string, error) {
 		return "", fmt.Errorf("failed to fetch content: %w", err)
 	}
 
-	// Find the main content node
+	// Drop menus, sidebars and footers, then find the main content node
+	stripBoilerplate(s.Content, s.Config.StripSelectors)
 	mainNode := findMainContentNode(s.Content)
 	if mainNode == nil {
 		return "", errors.New("could not find main content")
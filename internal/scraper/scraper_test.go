@@ -0,0 +1,147 @@
+package scraper
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// TestCrawlKeepsPagesDifferingByQuery checks that rel="next" pages such as ?page=2 are
+// recorded as pages of their own, and that their URL keeps the query.
+func TestCrawlKeepsPagesDifferingByQuery(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/blog", func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		if page == "" {
+			page = "1"
+		}
+		w.Header().Set("Content-Type", "text/html")
+		next := ""
+		if page != "3" {
+			next = fmt.Sprintf(`<link rel="next" href="/blog?page=%d">`, int(page[0]-'0')+1)
+		}
+		fmt.Fprintf(w, `<html><head>%s</head><body><main><p>Posts on page %s</p></main></body></html>`, next, page)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.RequestDelay = 0
+	s, err := New(server.URL+"/blog", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.GetAllPaths(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"/blog", "/blog?page=2", "/blog?page=3"} {
+		if _, ok := s.SubPathsHTMLContent[key]; !ok {
+			t.Errorf("%s not recorded, got paths %v", key, s.SubPaths)
+		}
+	}
+	if got, want := s.PageURL("/blog?page=2"), server.URL+"/blog?page=2"; got != want {
+		t.Errorf("PageURL(/blog?page=2) = %q, want %q", got, want)
+	}
+}
+
+// TestCrawlRecordsURLAfterCrossHostRedirect checks that once the starting page redirects to
+// another host, the pages crawled there are recorded under that host, not the original one.
+func TestCrawlRecordsURLAfterCrossHostRedirect(t *testing.T) {
+	target := http.NewServeMux()
+	target.HandleFunc("/{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><a href="/docs">Docs</a></body></html>`)
+	})
+	target.HandleFunc("/docs", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><main><p>Documentation</p></main></body></html>`)
+	})
+	moved := httptest.NewServer(target)
+	defer moved.Close()
+	origin := httptest.NewServer(http.RedirectHandler(moved.URL+"/", http.StatusMovedPermanently))
+	defer origin.Close()
+
+	config := DefaultConfig()
+	config.RequestDelay = 0
+	s, err := New(origin.URL+"/", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.GetAllPaths(); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]string{"/": moved.URL + "/", "/docs": moved.URL + "/docs"} {
+		if got := s.PageURL(key); got != want {
+			t.Errorf("PageURL(%s) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+// TestCrawlConcurrently crawls a site wide enough to keep every worker busy, so the
+// -race run exercises the shared crawl state.
+func TestCrawlConcurrently(t *testing.T) {
+	const sections, pagesPerSection = 5, 8
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><main><p>Index</p>`)
+		for i := 0; i < sections; i++ {
+			fmt.Fprintf(w, `<a href="/s%d">Section %d</a>`, i, i)
+		}
+		fmt.Fprint(w, `</main></body></html>`)
+	})
+	mux.HandleFunc("/{section}", func(w http.ResponseWriter, r *http.Request) {
+		section := r.PathValue("section")
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<html><body><main><p>Section %s</p><a href="/">Home</a>`, section)
+		for i := 0; i < pagesPerSection; i++ {
+			fmt.Fprintf(w, `<a href="/%s/p%d">Page %d</a>`, section, i, i)
+		}
+		fmt.Fprint(w, `</main></body></html>`)
+	})
+	mux.HandleFunc("/{section}/{page}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		// Every page links to its siblings, so workers race to claim the same URLs
+		fmt.Fprintf(w, `<html><body><main><p>Page %s</p><a href="/%s">Up</a><a href="/%s/p0">First</a></main></body></html>`,
+			r.URL.Path, r.PathValue("section"), r.PathValue("section"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.RequestDelay = 0
+	config.MaxConcurrent = 8
+	config.MaxPages = 0
+	var pages atomic.Int32
+	config.OnPage = func(url string, depth int, err error) {
+		if err != nil {
+			t.Errorf("crawling %s: %v", url, err)
+		}
+		pages.Add(1)
+	}
+	s, err := New(server.URL+"/", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.GetAllPaths(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := 1 + sections + sections*pagesPerSection
+	if got := len(s.SubPathsHTMLContent); got != want {
+		t.Errorf("recorded %d pages, want %d", got, want)
+	}
+	if got := int(pages.Load()); got != want {
+		t.Errorf("OnPage called %d times, want %d", got, want)
+	}
+	for i := 0; i < sections; i++ {
+		key := fmt.Sprintf("/s%d/p%d", i, pagesPerSection-1)
+		if _, ok := s.SubPathsHTMLContent[key]; !ok {
+			t.Errorf("%s not recorded", key)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package scraper
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+)
+
+// crawlStateVersion is the format version of the state files written during a crawl.
+const crawlStateVersion = 1
+
+// stateSaveEvery is how many pages are crawled between saves of Config.StateFile.
+const stateSaveEvery = 20
+
+// crawlState is what Config.StateFile holds: enough to continue an interrupted crawl.
+type crawlState struct {
+	Version int `json:"version"`
+	// ConfigHash identifies the settings that shape the crawl; see Config.crawlHash
+	ConfigHash string `json:"config_hash"`
+	// Base is the scheme and host the crawl stays on
+	Base string `json:"base"`
+	// Frontier holds the pages still to be fetched, including those in flight
+	Frontier []stateTask `json:"frontier"`
+	// Visited holds every URL already fetched or queued
+	Visited []string `json:"visited"`
+	// Result holds the pages collected so far
+	Result *CrawlResult `json:"result"`
+}
+
+// stateTask is a crawlTask as stored in a state file.
+type stateTask struct {
+	URL   string `json:"url"`
+	Depth int    `json:"depth"`
+}
+
+// crawlHash returns a fingerprint of the settings that decide which pages a crawl
+// visits and how they are converted, so a crawl is only resumed with the same ones.
+func (c *Config) crawlHash() string {
+	data, _ := json.Marshal(struct {
+		MaxDepth                 int
+		MaxPages                 int
+		IncludePatterns          []string
+		ExcludePatterns          []string
+		RestrictToPathPrefix     bool
+		PathPrefix               string
+		StripQueryParams         []string
+		StripSelectors           []string
+		LinkElements             []string
+		FollowCrossHostRedirects bool
+		MetadataOnly             bool
+	}{
+		c.MaxDepth, c.MaxPages, c.IncludePatterns, c.ExcludePatterns, c.RestrictToPathPrefix, c.PathPrefix,
+		c.StripQueryParams, c.StripSelectors, c.LinkElements, c.FollowCrossHostRedirects, c.MetadataOnly,
+	})
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
+
+// saveState writes the crawl's progress to Config.StateFile. It is called from the
+// crawl coordinator, which owns base, pending and visited.
+func (s *Scraper) saveState(base *url.URL, pending []crawlTask, visited, paths map[string]bool) error {
+	state := crawlState{
+		Version:    crawlStateVersion,
+		ConfigHash: s.Config.crawlHash(),
+		Base:       base.String(),
+		Frontier:   make([]stateTask, 0, len(pending)),
+		Visited:    make([]string, 0, len(visited)),
+		Result:     s.Result(),
+	}
+	for _, task := range pending {
+		state.Frontier = append(state.Frontier, stateTask{URL: task.url.String(), Depth: task.depth})
+	}
+	for u := range visited {
+		state.Visited = append(state.Visited, u)
+	}
+	// Workers add to paths as they go; SubPaths is only filled in once the crawl ends
+	s.mutex.Lock()
+	state.Result.Paths = make([]string, 0, len(paths))
+	for path := range paths {
+		state.Result.Paths = append(state.Result.Paths, path)
+	}
+	s.mutex.Unlock()
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode crawl state: %w", err)
+	}
+	// Write to a temporary file first so a crash mid-write keeps the previous state
+	tmp := s.Config.StateFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write crawl state: %w", err)
+	}
+	if err := os.Rename(tmp, s.Config.StateFile); err != nil {
+		return fmt.Errorf("failed to write crawl state: %w", err)
+	}
+	return nil
+}
+
+// ResumeFrom continues a crawl from a state file saved through Config.StateFile.
+//
+// The pages collected before the interruption are restored as with Restore, and the
+// crawl carries on with the pages that were still queued or in flight. Progress keeps
+// being saved to the same file. Resuming a crawl that had finished fetches nothing and
+// just restores its pages.
+//
+// Parameters:
+//   - stateFile: The state file to resume from
+//
+// Returns:
+//   - An error if the state file cannot be read, belongs to a crawl of another URL or
+//     with different settings, or if the resumed crawl fails or is cancelled
+func (s *Scraper) ResumeFrom(stateFile string) error {
+	return s.ResumeWithContext(context.Background(), stateFile)
+}
+
+// ResumeWithContext is ResumeFrom with a context that cancels the resumed crawl.
+//
+// Parameters:
+//   - ctx: Controls cancellation and deadline of the resumed crawl
+//   - stateFile: The state file to resume from
+//
+// Returns:
+//   - An error as described for ResumeFrom
+func (s *Scraper) ResumeWithContext(ctx context.Context, stateFile string) error {
+	data, err := os.ReadFile(stateFile)
+	if err != nil {
+		return fmt.Errorf("failed to read crawl state: %w", err)
+	}
+	var state crawlState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to decode crawl state: %w", err)
+	}
+	if state.Version != crawlStateVersion || state.Result == nil {
+		return fmt.Errorf("unsupported crawl state version %d", state.Version)
+	}
+	if state.Result.URL != s.URL {
+		return fmt.Errorf("crawl state is for %s, not %s", state.Result.URL, s.URL)
+	}
+	if state.ConfigHash != s.Config.crawlHash() {
+		return errors.New("crawl state was saved with different crawl settings")
+	}
+	base, err := url.Parse(state.Base)
+	if err != nil {
+		return fmt.Errorf("invalid base URL in crawl state: %w", err)
+	}
+
+	frontier := make([]crawlTask, 0, len(state.Frontier))
+	for _, task := range state.Frontier {
+		u, err := url.Parse(task.URL)
+		if err != nil {
+			return fmt.Errorf("invalid URL in crawl state: %w", err)
+		}
+		frontier = append(frontier, crawlTask{url: u, depth: task.Depth})
+	}
+	visited := make(map[string]bool, len(state.Visited))
+	for _, u := range state.Visited {
+		visited[u] = true
+	}
+	paths := make(map[string]bool, len(state.Result.Paths))
+	for _, path := range state.Result.Paths {
+		paths[path] = true
+	}
+
+	s.Restore(state.Result)
+	s.Config.StateFile = stateFile
+
+	err = s.crawl(ctx, base, nil, frontier, paths, visited)
+	s.SubPaths = make([]string, 0, len(paths))
+	for path := range paths {
+		s.SubPaths = append(s.SubPaths, path)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		return fmt.Errorf("crawling failed: %w", err)
+	}
+	return nil
+}
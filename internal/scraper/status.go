@@ -0,0 +1,68 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// CheckStatus asks the server for urlStr and returns the HTTP status code it answers with,
+// following redirects the same way crawling does. It sends a HEAD request and falls back
+// to GET when the server doesn't support HEAD, so the page body is normally not downloaded.
+//
+// Like a crawl, the request goes through the per-host rate limiter and is sent with the
+// configured User-Agent, headers, basic auth and proxy. It is not retried.
+//
+// Parameters:
+//   - ctx: Context for cancelling the request
+//   - urlStr: The URL to check
+//
+// Returns:
+//   - The HTTP status code of the final response
+//   - An error if the request could not be sent or the context was cancelled
+func (s *Scraper) CheckStatus(ctx context.Context, urlStr string) (int, error) {
+	status, err := s.requestStatus(ctx, http.MethodHead, urlStr)
+	if err != nil {
+		return 0, err
+	}
+	if status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented {
+		return s.requestStatus(ctx, http.MethodGet, urlStr)
+	}
+	return status, nil
+}
+
+// requestStatus sends one rate-limited request with method and returns its status code,
+// discarding the body.
+func (s *Scraper) requestStatus(ctx context.Context, method, urlStr string) (int, error) {
+	parsedURL, err := url.Parse(urlStr)
+	if err != nil {
+		return 0, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	if err := s.waitForRateLimit(ctx, parsedURL.Host); err != nil {
+		return 0, err
+	}
+	defer func() { <-s.requestSem }()
+
+	req, err := http.NewRequestWithContext(ctx, method, urlStr, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", s.Config.UserAgent)
+	for name, value := range s.Config.Headers {
+		req.Header.Set(name, value)
+	}
+	if s.Config.BasicAuthUser != "" {
+		req.SetBasicAuth(s.Config.BasicAuthUser, s.Config.BasicAuthPass)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
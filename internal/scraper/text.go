@@ -0,0 +1,230 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// skippedTextElements are never included in structured text.
+var skippedTextElements = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true,
+	"nav": true, "footer": true,
+}
+
+// paragraphElements are separated from surrounding text by a blank line.
+var paragraphElements = map[string]bool{
+	"p": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"blockquote": true, "table": true, "figure": true,
+}
+
+// lineElements start and end on their own line.
+var lineElements = map[string]bool{
+	"div": true, "section": true, "article": true, "main": true, "header": true, "aside": true,
+	"tr": true, "dl": true, "dt": true, "dd": true, "figcaption": true, "details": true, "summary": true,
+}
+
+// ScrapeStructuredContent fetches the URL and extracts the main content as plain text
+// that keeps the page's layout.
+//
+// Unlike ScrapeContent, block-level elements such as paragraphs, headings, list items
+// and table rows are placed on their own lines, list items keep a "- " or "1. " marker,
+// and table cells are separated by " | ". Script, style, nav and footer elements are skipped,
+// as is anything matching Config.StripSelectors.
+//
+// Returns:
+//   - The extracted text content or an error if scraping fails
+func (s *Scraper) ScrapeStructuredContent() (string, error) {
+	err := s.GetContent()
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch content: %w", err)
+	}
+
+	stripBoilerplate(s.Content, s.Config.StripSelectors)
+	mainNode := findMainContentNode(s.Content)
+	if mainNode == nil {
+		return "", errors.New("could not find main content")
+	}
+
+	return extractStructuredText(mainNode), nil
+}
+
+// extractStructuredText extracts the text of an HTML node, preserving block boundaries and list markers.
+func extractStructuredText(n *html.Node) string {
+	if n == nil {
+		return ""
+	}
+
+	var t structuredText
+	t.walk(n, 0)
+
+	lines := strings.Split(t.b.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// structuredText accumulates text while collapsing whitespace the way a browser would.
+type structuredText struct {
+	b strings.Builder
+	// newlines is how many newlines the buffer currently ends with
+	newlines int
+	// pendingSpace records collapsed whitespace not yet written
+	pendingSpace bool
+}
+
+// walk writes the text of n and its descendants. listDepth is the current list nesting level.
+func (t *structuredText) walk(n *html.Node, listDepth int) {
+	switch n.Type {
+	case html.TextNode:
+		t.text(n.Data)
+		return
+	case html.ElementNode, html.DocumentNode:
+	default:
+		return
+	}
+
+	if n.Type == html.ElementNode {
+		switch {
+		case skippedTextElements[n.Data]:
+			return
+		case n.Data == "br":
+			t.newline(1)
+			return
+		case n.Data == "pre":
+			t.newline(2)
+			t.raw(strings.Trim(textContent(n), "\n"))
+			t.newline(2)
+			return
+		case n.Data == "ul" || n.Data == "ol":
+			t.list(n, listDepth)
+			return
+		case n.Data == "tr":
+			t.row(n, listDepth)
+			return
+		}
+	}
+
+	breaks := 0
+	if n.Type == html.ElementNode {
+		if paragraphElements[n.Data] {
+			breaks = 2
+		} else if lineElements[n.Data] {
+			breaks = 1
+		}
+	}
+
+	t.newline(breaks)
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		t.walk(c, listDepth)
+	}
+	t.newline(breaks)
+}
+
+// list writes each <li> of a list on its own line with an indented marker.
+func (t *structuredText) list(n *html.Node, listDepth int) {
+	// Top-level lists are paragraphs of their own; nested lists continue their item
+	breaks := 2
+	if listDepth > 0 {
+		breaks = 1
+	}
+	t.newline(breaks)
+
+	index := 0
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.Data != "li" {
+			t.walk(c, listDepth)
+			continue
+		}
+		index++
+		marker := "- "
+		if n.Data == "ol" {
+			marker = fmt.Sprintf("%d. ", index)
+		}
+		t.newline(1)
+		t.raw(strings.Repeat("  ", listDepth) + marker)
+		for gc := c.FirstChild; gc != nil; gc = gc.NextSibling {
+			t.walk(gc, listDepth+1)
+		}
+	}
+
+	t.newline(breaks)
+}
+
+// row writes a table row on one line with cells separated by " | ".
+func (t *structuredText) row(n *html.Node, listDepth int) {
+	t.newline(1)
+	first := true
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && (c.Data == "td" || c.Data == "th") {
+			if !first {
+				t.raw(" | ")
+			}
+			first = false
+		}
+		t.walk(c, listDepth)
+	}
+	t.newline(1)
+}
+
+// text writes a text node, collapsing runs of whitespace into single spaces.
+func (t *structuredText) text(s string) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		if s != "" {
+			t.pendingSpace = true
+		}
+		return
+	}
+
+	if strings.TrimLeft(s, " \t\r\n\f") != s {
+		t.pendingSpace = true
+	}
+	if t.pendingSpace && t.b.Len() > 0 && t.newlines == 0 {
+		t.b.WriteByte(' ')
+	}
+	t.b.WriteString(strings.Join(fields, " "))
+	t.newlines = 0
+	t.pendingSpace = strings.TrimRight(s, " \t\r\n\f") != s
+}
+
+// raw writes s verbatim.
+func (t *structuredText) raw(s string) {
+	if s == "" {
+		return
+	}
+	t.b.WriteString(s)
+	t.newlines = len(s) - len(strings.TrimRight(s, "\n"))
+	t.pendingSpace = false
+}
+
+// newline ends the current line and makes sure the text ends with at least count newlines.
+// Nothing is written at the very start of the text.
+func (t *structuredText) newline(count int) {
+	if count == 0 {
+		return
+	}
+	t.pendingSpace = false
+	if t.b.Len() == 0 {
+		return
+	}
+	for t.newlines < count {
+		t.b.WriteByte('\n')
+		t.newlines++
+	}
+}
+
+// textContent returns the raw text of n and its descendants.
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textContent(c))
+	}
+	return b.String()
+}
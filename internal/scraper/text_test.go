@@ -0,0 +1,75 @@
+package scraper
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// layoutFixture is a page whose main content mixes lists, a table and code blocks.
+const layoutFixture = `<!DOCTYPE html>
+<html>
+<head><title>Install</title><script>var tracking = true;</script></head>
+<body>
+<nav><a href="/">Home</a> <a href="/docs">Docs</a></nav>
+<main>
+  <h1>Installing   pons</h1>
+  <p>Pick the
+     method that suits you.</p>
+  <ul>
+    <li>Homebrew</li>
+    <li>From source
+      <ol>
+        <li>Clone the repository</li>
+        <li>Run <code>make</code></li>
+      </ol>
+    </li>
+  </ul>
+  <table>
+    <tr><th>Platform</th><th>Binary</th></tr>
+    <tr><td>Linux</td><td>pons-linux</td></tr>
+    <tr><td>macOS</td><td>pons-darwin</td></tr>
+  </table>
+  <pre><code>go build -o pons .
+./pons   version</code></pre>
+  <p>Then run <code>pons add</code>.</p>
+</main>
+<footer>Copyright</footer>
+</body>
+</html>`
+
+const layoutFixtureText = `Installing pons
+
+Pick the method that suits you.
+
+- Homebrew
+- From source
+  1. Clone the repository
+  2. Run make
+
+Platform | Binary
+Linux | pons-linux
+macOS | pons-darwin
+
+go build -o pons .
+./pons   version
+
+Then run pons add.`
+
+func TestScrapeStructuredContentKeepsLayout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, layoutFixture)
+	}))
+	defer server.Close()
+
+	s := newTestScraper(t, server.URL)
+	text, err := s.ScrapeStructuredContent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != layoutFixtureText {
+		t.Errorf("got:\n%s\n\nwant:\n%s", text, layoutFixtureText)
+	}
+}
@@ -0,0 +1,107 @@
+package scraper
+
+import (
+	"net/url"
+	"strings"
+)
+
+// DefaultStripQueryParams lists the tracking query parameters removed during URL normalization.
+// A trailing "*" matches any parameter with that prefix.
+var DefaultStripQueryParams = []string{"utm_*", "fbclid", "gclid"}
+
+// normalizeURL returns a canonical copy of u so that trivially different URLs for the
+// same page share a single visited entry. The canonical form is only a dedupe key: pages
+// are still fetched from the URL as linked, since a server may answer /docs/ but not /docs.
+//
+// It lowercases the scheme and host, drops the default port and the fragment, removes
+// query parameters matching stripParams, sorts the remaining query, and collapses
+// trailing slashes (the root path "/" is kept).
+//
+// Parameters:
+//   - u: The absolute URL to normalize
+//   - stripParams: Query parameter names to drop; a trailing "*" matches by prefix
+//
+// Returns:
+//   - The normalized URL
+func normalizeURL(u *url.URL, stripParams []string) *url.URL {
+	n := *u
+	n.Scheme = strings.ToLower(n.Scheme)
+	n.Host = strings.ToLower(n.Host)
+	if port := n.Port(); (n.Scheme == "http" && port == "80") || (n.Scheme == "https" && port == "443") {
+		n.Host = n.Hostname()
+		if strings.Contains(n.Host, ":") {
+			n.Host = "[" + n.Host + "]" // IPv6 literal
+		}
+	}
+	n.Fragment = ""
+	n.RawFragment = ""
+
+	if n.RawQuery != "" {
+		query := n.Query()
+		for key := range query {
+			if matchesParam(key, stripParams) {
+				query.Del(key)
+			}
+		}
+		// Encode sorts by key, which also makes parameter order irrelevant
+		n.RawQuery = query.Encode()
+	}
+
+	trimmed := strings.TrimRight(n.Path, "/")
+	if trimmed == "" {
+		trimmed = "/"
+	}
+	if trimmed != n.Path {
+		n.Path = trimmed
+		n.RawPath = ""
+	}
+
+	return &n
+}
+
+// urlKey returns the key under which u is recorded as visited: its canonical form.
+func urlKey(u *url.URL, stripParams []string) string {
+	return normalizeURL(u, stripParams).String()
+}
+
+// pageKey returns the key a crawled page is recorded under in SubPaths and the SubPaths*
+// maps: its path, "/" for the bare host, followed by its query, since pages such as
+// rel="next" pagination often differ only by query.
+func pageKey(u *url.URL) string {
+	key := u.Path
+	if key == "" {
+		key = "/"
+	}
+	if u.RawQuery != "" {
+		key += "?" + u.RawQuery
+	}
+	return key
+}
+
+// withoutFragment returns a copy of u without its fragment, which is never sent to the server.
+func withoutFragment(u *url.URL) *url.URL {
+	n := *u
+	n.Fragment = ""
+	n.RawFragment = ""
+	return &n
+}
+
+// matchesParam reports whether a query parameter name matches any of the patterns.
+func matchesParam(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// isHTTPScheme reports whether a URL scheme can be crawled.
+func isHTTPScheme(scheme string) bool {
+	scheme = strings.ToLower(scheme)
+	return scheme == "http" || scheme == "https"
+}
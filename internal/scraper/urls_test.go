@@ -0,0 +1,138 @@
+package scraper
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"fragment", "https://example.com/docs#intro", "https://example.com/docs"},
+		{"default http port", "http://example.com:80/docs", "http://example.com/docs"},
+		{"default https port", "https://example.com:443/docs", "https://example.com/docs"},
+		{"non-default port", "https://example.com:8443/docs", "https://example.com:8443/docs"},
+		{"http port on https", "https://example.com:80/docs", "https://example.com:80/docs"},
+		{"ipv6 default port", "http://[::1]:80/docs", "http://[::1]/docs"},
+		{"scheme and host case", "HTTPS://Example.COM/Docs", "https://example.com/Docs"},
+		{"trailing slash", "https://example.com/docs/", "https://example.com/docs"},
+		{"repeated trailing slashes", "https://example.com/docs//", "https://example.com/docs"},
+		{"root path", "https://example.com/", "https://example.com/"},
+		{"query order", "https://example.com/s?b=2&a=1", "https://example.com/s?a=1&b=2"},
+		{"tracking params", "https://example.com/s?utm_source=x&q=go&fbclid=y", "https://example.com/s?q=go"},
+		{"only tracking params", "https://example.com/s?utm_medium=x", "https://example.com/s"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := normalizeURL(u, DefaultStripQueryParams).String(); got != tt.want {
+				t.Errorf("normalizeURL(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeURLDoesNotModifyInput(t *testing.T) {
+	u, _ := url.Parse("https://Example.com:443/docs/?b=1&a=2#top")
+	before := u.String()
+	normalizeURL(u, nil)
+	if u.String() != before {
+		t.Errorf("input changed to %q, want %q", u.String(), before)
+	}
+}
+
+func TestExtractLinksSchemes(t *testing.T) {
+	tests := []struct {
+		name string
+		href string
+		want string // "" when the link must be skipped
+	}{
+		{"relative", "docs/intro", "https://example.com/guide/docs/intro"},
+		{"absolute path", "/api", "https://example.com/api"},
+		{"absolute https", "https://example.com/blog", "https://example.com/blog"},
+		{"uppercase scheme", "HTTPS://example.com/blog", "https://example.com/blog"},
+		{"protocol-relative", "//example.com/faq", "https://example.com/faq"},
+		{"protocol-relative other host", "//cdn.example.net/faq", ""},
+		{"fragment only", "#top", "https://example.com/guide/"},
+		{"javascript", "javascript:void(0)", ""},
+		{"javascript uppercase", "JavaScript:alert(1)", ""},
+		{"mailto", "mailto:docs@example.com", ""},
+		{"tel", "tel:+15551234567", ""},
+		{"data", "data:text/html,<p>hi</p>", ""},
+		{"ftp", "ftp://example.com/file", ""},
+	}
+	pageURL, _ := url.Parse("https://example.com/guide/")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader(`<a href="` + html.EscapeString(tt.href) + `">link</a>`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			links := extractLinks(doc, pageURL, "example.com", map[string]bool{}, nil, DefaultLinkElements)
+			var got string
+			if len(links) > 0 {
+				got = links[0].String()
+			}
+			if len(links) > 1 || got != tt.want {
+				t.Errorf("extractLinks(%q) = %v, want %q", tt.href, links, tt.want)
+			}
+		})
+	}
+}
+
+// TestCrawlFetchesLinkedURL checks that the canonical form is only used for dedupe: a
+// page linked as /docs/ is fetched and recorded as /docs/, though its visited key is /docs.
+func TestCrawlFetchesLinkedURL(t *testing.T) {
+	var mu sync.Mutex
+	fetched := make(map[string]int)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetched[r.URL.Path]++
+		mu.Unlock()
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><a href="/docs/">Docs</a><a href="/docs/#top">Docs again</a></body></html>`)
+	})
+	mux.HandleFunc("/docs/{$}", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetched[r.URL.Path]++
+		mu.Unlock()
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><main><p>Documentation</p></main></body></html>`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.RequestDelay = 0
+	config.MaxConcurrent = 1
+	s, err := New(server.URL+"/", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.GetAllPaths(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if fetched["/docs/"] != 1 {
+		t.Errorf("/docs/ fetched %d times, want 1", fetched["/docs/"])
+	}
+	if _, ok := s.SubPathsHTMLContent["/docs/"]; !ok {
+		t.Errorf("/docs/ not recorded, got paths %v", s.SubPaths)
+	}
+}
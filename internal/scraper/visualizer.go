@@ -2,7 +2,6 @@ package scraper
 
 import (
 	"fmt"
-	"os"
 	"strconv"
 	"sync"
 	"time"
@@ -21,17 +20,23 @@ func (s *Scraper) displayInitBanner() {
 		banner += fmt.Sprintf("Target URL: %s\n", s.URL)
 		banner += "Configuration:\n"
 		banner += fmt.Sprintf("  - Max Depth: %d\n", s.Config.MaxDepth)
+		if s.Config.MaxPages > 0 {
+			banner += fmt.Sprintf("  - Max Pages: %d\n", s.Config.MaxPages)
+		}
 		banner += fmt.Sprintf("  - Request Delay: %s\n", s.Config.RequestDelay)
 		banner += fmt.Sprintf("  - Max Concurrent: %d\n", s.Config.MaxConcurrent)
+		if s.Config.Adaptive {
+			banner += fmt.Sprintf("  - Adaptive: from %d concurrent\n", s.tuner.min)
+		}
 		banner += "=============================================================================="
-		fmt.Println(banner)
+		s.Logger.Print(banner)
 	}
 }
 
 func (s *Scraper) displayMetadata() {
 	if s.Verbose {
 		t := table.NewWriter()
-		t.SetOutputMirror(os.Stdout)
+		t.SetOutputMirror(s.Logger.Writer())
 		t.SetStyle(table.StyleLight) // Consistent with displaySubpathResults
 		t.AppendHeader(table.Row{"Field", "Value"})
 		t.SetColumnConfigs([]table.ColumnConfig{
@@ -42,6 +47,9 @@ func (s *Scraper) displayMetadata() {
 		// Add Title and Description rows
 		t.AppendRow(table.Row{"Title", s.Metadata.Title})
 		t.AppendRow(table.Row{"Description", s.Metadata.Description})
+		if s.Metadata.Canonical != "" {
+			t.AppendRow(table.Row{"Canonical", s.Metadata.Canonical})
+		}
 		t.AppendSeparator() // Adds row lines between entries
 		t.Render()
 	}
@@ -56,7 +64,7 @@ func (s *Scraper) displayCrawlStartBanner() {
 		banner += fmt.Sprintf("Base URL: %s\n", s.URL)
 		banner += fmt.Sprintf("Max Depth: %d\n", s.Config.MaxDepth)
 		banner += "=============================================================================="
-		fmt.Println(banner)
+		s.Logger.Print(banner)
 	}
 }
 
@@ -68,14 +76,14 @@ func (s *Scraper) displayCrawlEndBanner() {
 		banner += "==============================================================================\n"
 		banner += fmt.Sprintf("Found %d subpaths.\n", len(s.SubPaths))
 		banner += "=============================================================================="
-		fmt.Println(banner)
+		s.Logger.Print(banner)
 	}
 }
 
 func (s *Scraper) displaySubpathResults() {
 	if s.Verbose {
 		t := table.NewWriter()
-		t.SetOutputMirror(os.Stdout)
+		t.SetOutputMirror(s.Logger.Writer())
 		t.SetStyle(table.StyleLight) // Use light style for minimal borders
 		t.AppendHeader(table.Row{"Path", "HTML Length"})
 		t.SetColumnConfigs([]table.ColumnConfig{
@@ -92,20 +100,20 @@ func (s *Scraper) displaySubpathResults() {
 	}
 }
 
+// displayError logs err to s.Logger, which discards it unless the scraper is verbose
+// or a logger was set.
 func (s *Scraper) displayError(err error) {
-	if s.Verbose {
-		red := color.New(color.FgRed).SprintFunc()
-		box := "┌────── " + red("⚠ Error") + " ──────┐\n"
-		box += fmt.Sprintf("│ %-20s │\n", err.Error())
-		box += "└─────────────────────┘"
-		fmt.Println(box)
-	}
+	red := color.New(color.FgRed).SprintFunc()
+	box := "┌────── " + red("⚠ Error") + " ──────┐\n"
+	box += fmt.Sprintf("│ %-20s │\n", err.Error())
+	box += "└─────────────────────┘"
+	s.Logger.Print(box)
 }
 
 func (s *Scraper) startSpinner(message string) chan struct{} {
 	done := make(chan struct{})
 	if s.Verbose {
-		os.Stdout.Sync()
+		out := s.Logger.Writer()
 		go func() {
 			spinner := `|/-\`
 			i := 0
@@ -116,14 +124,12 @@ func (s *Scraper) startSpinner(message string) chan struct{} {
 				select {
 				case <-ticker.C:
 					mu.Lock()
-					fmt.Fprintf(os.Stdout, "\r%s... [%s]", color.YellowString("%s", message), string(spinner[i]))
-					os.Stdout.Sync()
+					fmt.Fprintf(out, "\r%s... [%s]", color.YellowString("%s", message), string(spinner[i]))
 					mu.Unlock()
 					i = (i + 1) % len(spinner)
 				case <-done:
 					mu.Lock()
-					fmt.Fprintf(os.Stdout, "\r%s... [%s]\n", color.GreenString("%s", message), "✔")
-					os.Stdout.Sync()
+					fmt.Fprintf(out, "\r%s... [%s]\n", color.GreenString("%s", message), "✔")
 					mu.Unlock()
 					return
 				}
@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// embeddingComponentSize is the number of bytes each embedding component takes when encoded.
+const embeddingComponentSize = 4
+
+// MarshalEmbedding encodes an embedding for the embeddings column as consecutive
+// little-endian float32 values. An empty embedding encodes to nil, stored as NULL.
+func MarshalEmbedding(v []float32) []byte {
+	if len(v) == 0 {
+		return nil
+	}
+	b := make([]byte, len(v)*embeddingComponentSize)
+	for i, x := range v {
+		binary.LittleEndian.PutUint32(b[i*embeddingComponentSize:], math.Float32bits(x))
+	}
+	return b
+}
+
+// UnmarshalEmbedding decodes an embedding encoded by MarshalEmbedding. NULL and empty
+// values decode to a nil embedding.
+func UnmarshalEmbedding(b []byte) ([]float32, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	if len(b)%embeddingComponentSize != 0 {
+		return nil, fmt.Errorf("invalid embedding length %d", len(b))
+	}
+	v := make([]float32, len(b)/embeddingComponentSize)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[i*embeddingComponentSize:]))
+	}
+	return v, nil
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Export writes every document in a context (all documents when context is empty) to w
+// as JSON Lines, one document per line. Rows are streamed, so the store is never loaded
+// into memory at once. Without includeEmbeddings, embeddings are written as null.
+func (s *Storage) Export(w io.Writer, context string, includeEmbeddings bool) error {
+	enc := json.NewEncoder(w)
+	return s.EachDocument(context, func(doc *Document) error {
+		if !includeEmbeddings {
+			doc.Embeddings = nil
+			doc.EmbeddingModel = ""
+			doc.EmbeddingNorm = 0
+		}
+		if err := enc.Encode(doc); err != nil {
+			return fmt.Errorf("failed to write document %s: %v", doc.URL, err)
+		}
+		return nil
+	})
+}
+
+// Import reads documents in the JSON Lines format written by Export and upserts them in a
+// single transaction. Blank lines are ignored; lines that aren't a JSON document with a
+// URL, or that prepare rejects, are skipped and counted rather than aborting the import.
+// prepare, if set, is called on each document before it is stored, e.g. to fill in
+// missing embeddings.
+//
+// Returns the number of documents imported and the number of lines skipped.
+func (s *Storage) Import(r io.Reader, prepare func(*Document) error) (imported, skipped int, err error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(upsertStatement)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to prepare upsert statement: %v", err)
+	}
+	defer stmt.Close()
+
+	// Lines holding embeddings are too long for a bufio.Scanner's default buffer
+	reader := bufio.NewReader(r)
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return 0, 0, fmt.Errorf("failed to read import: %v", readErr)
+		}
+
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			var doc Document
+			switch {
+			case json.Unmarshal(line, &doc) != nil || doc.URL == "":
+				skipped++
+			case prepare != nil && prepare(&doc) != nil:
+				skipped++
+			default:
+				if err := upsertDocument(tx, stmt, &doc); err != nil {
+					return 0, 0, fmt.Errorf("failed to import %s: %v", doc.URL, err)
+				}
+				imported++
+			}
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit import: %v", err)
+	}
+	return imported, skipped, nil
+}
@@ -0,0 +1,227 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// migrations evolve the schema one step at a time. The database's PRAGMA user_version
+// records how many have been applied, so only append to this list: never reorder or
+// edit a migration that has shipped.
+//
+// Databases created before versioning existed have user_version 0 but may already
+// have some later columns, so column additions go through addColumn, which skips
+// columns that are already there.
+var migrations = []func(tx *sql.Tx) error{
+	// 1: the original documents table
+	func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS documents (
+			url TEXT PRIMARY KEY,
+			title TEXT,
+			description TEXT,
+			content TEXT,
+			checksum TEXT,
+			embeddings BLOB,
+			context TEXT,
+			source_type TEXT
+		);`)
+		return err
+	},
+	// 2: section anchors for deep links
+	func(tx *sql.Tx) error {
+		return addColumn(tx, "documents", "anchor", "TEXT")
+	},
+	// 3: content language
+	func(tx *sql.Tx) error {
+		return addColumn(tx, "documents", "language", "TEXT")
+	},
+	// 4: HTTP validators for conditional requests
+	func(tx *sql.Tx) error {
+		if err := addColumn(tx, "documents", "etag", "TEXT"); err != nil {
+			return err
+		}
+		return addColumn(tx, "documents", "last_modified", "TEXT")
+	},
+	// 5: crawl depth
+	func(tx *sql.Tx) error {
+		return addColumn(tx, "documents", "depth", "INTEGER")
+	},
+	// 6: which embedding model produced the stored embeddings
+	func(tx *sql.Tx) error {
+		return addColumn(tx, "documents", "embedding_model", "TEXT")
+	},
+	// 7: the URL the crawl that stored the document started from
+	func(tx *sql.Tx) error {
+		return addColumn(tx, "documents", "seed_url", "TEXT")
+	},
+	// 8: store-wide metadata, seeded with the most common embedding dimension
+	func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS metadata (
+			key TEXT PRIMARY KEY,
+			value TEXT
+		);`); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`
+		INSERT OR IGNORE INTO metadata (key, value)
+		SELECT ?, json_array_length(embeddings) AS dimension
+		FROM documents
+		WHERE json_valid(embeddings) AND json_type(embeddings) = 'array' AND json_array_length(embeddings) > 0
+		GROUP BY dimension
+		ORDER BY COUNT(*) DESC, dimension
+		LIMIT 1`, embeddingDimensionKey)
+		return err
+	},
+	// 9: free-form labels, stored as a JSON array
+	func(tx *sql.Tx) error {
+		return addColumn(tx, "documents", "tags", "TEXT")
+	},
+	// 10: embeddings are normalized on write from now on; an empty store holds no
+	// older, unnormalized vectors
+	func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+		INSERT OR IGNORE INTO metadata (key, value)
+		SELECT ?, 'true' WHERE NOT EXISTS (SELECT 1 FROM documents)`, embeddingsNormalizedKey)
+		return err
+	},
+	// 11: embeddings are stored as binary float32 rather than JSON text
+	convertEmbeddingsToBinary,
+	// 12: the length each embedding had before it was normalized, for metrics other than cosine
+	func(tx *sql.Tx) error {
+		return addColumn(tx, "documents", "embedding_norm", "REAL")
+	},
+	// 13: the URL a page was fetched from, when it is stored under its canonical URL
+	func(tx *sql.Tx) error {
+		return addColumn(tx, "documents", "fetch_url", "TEXT")
+	},
+}
+
+// maxListedURLs caps how many documents a migration error names.
+const maxListedURLs = 10
+
+// convertEmbeddingsToBinary re-encodes every JSON-encoded embedding with MarshalEmbedding.
+// An embedding that isn't a valid JSON array fails the migration, naming the documents
+// affected, rather than being silently dropped.
+func convertEmbeddingsToBinary(tx *sql.Tx) error {
+	type update struct {
+		url        string
+		embeddings []byte
+	}
+	var updates []update
+	var invalid []string
+	rows, err := tx.Query("SELECT url, embeddings FROM documents WHERE embeddings IS NOT NULL")
+	if err != nil {
+		return fmt.Errorf("failed to query embeddings: %v", err)
+	}
+	for rows.Next() {
+		var url string
+		var raw []byte
+		if err := rows.Scan(&url, &raw); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan embeddings: %v", err)
+		}
+		var embeddings []float32
+		if len(raw) > 0 {
+			if err := json.Unmarshal(raw, &embeddings); err != nil {
+				invalid = append(invalid, url)
+				continue
+			}
+		}
+		updates = append(updates, update{url: url, embeddings: MarshalEmbedding(embeddings)})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate embeddings: %v", err)
+	}
+	if len(invalid) > 0 {
+		listed := strings.Join(invalid[:min(len(invalid), maxListedURLs)], ", ")
+		if len(invalid) > maxListedURLs {
+			listed += fmt.Sprintf(" and %d more", len(invalid)-maxListedURLs)
+		}
+		return fmt.Errorf("cannot convert the embeddings of %d documents, which are not a JSON array: %s; fix or delete these rows and try again", len(invalid), listed)
+	}
+
+	stmt, err := tx.Prepare("UPDATE documents SET embeddings = ? WHERE url = ?")
+	if err != nil {
+		return fmt.Errorf("failed to prepare update statement: %v", err)
+	}
+	defer stmt.Close()
+	for _, u := range updates {
+		if _, err := stmt.Exec(u.embeddings, u.url); err != nil {
+			return fmt.Errorf("failed to convert embeddings for %s: %v", u.url, err)
+		}
+	}
+	return nil
+}
+
+// migrate applies every pending migration in a single transaction, so a failure
+// leaves the database at its previous version.
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version: %v", err)
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than this version of pons supports (%d)", version, len(migrations))
+	}
+	if version == len(migrations) {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start migration: %v", err)
+	}
+	defer tx.Rollback()
+
+	for i := version; i < len(migrations); i++ {
+		if err := migrations[i](tx); err != nil {
+			return fmt.Errorf("failed to apply migration %d: %v", i+1, err)
+		}
+	}
+	// PRAGMA doesn't take bound parameters
+	if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", len(migrations))); err != nil {
+		return fmt.Errorf("failed to update schema version: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration: %v", err)
+	}
+	return nil
+}
+
+// addColumn adds a column to a table unless it already exists.
+func addColumn(tx *sql.Tx, table, column, columnType string) error {
+	rows, err := tx.Query("PRAGMA table_info(" + table + ")")
+	if err != nil {
+		return fmt.Errorf("failed to inspect %s table: %v", table, err)
+	}
+	exists := false
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to inspect %s table: %v", table, err)
+		}
+		if name == column {
+			exists = true
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to inspect %s table: %v", table, err)
+	}
+
+	if exists {
+		return nil
+	}
+	if _, err := tx.Exec("ALTER TABLE " + table + " ADD COLUMN " + column + " " + columnType); err != nil {
+		return fmt.Errorf("failed to add %s column: %v", column, err)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"database/sql"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// oldSchema is the documents table as pons created it before the schema was versioned,
+// with embeddings stored as JSON text.
+const oldSchema = `
+	CREATE TABLE documents (
+		url TEXT PRIMARY KEY,
+		title TEXT,
+		description TEXT,
+		content TEXT,
+		checksum TEXT,
+		embeddings BLOB,
+		context TEXT,
+		source_type TEXT
+	);`
+
+// newOldDatabase creates a database with the pre-versioning schema holding rows, given
+// as url and JSON embeddings pairs, and returns its path.
+func newOldDatabase(t *testing.T, rows ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "old.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(oldSchema); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < len(rows); i += 2 {
+		_, err := db.Exec(`INSERT INTO documents VALUES (?, 'Title', 'Description', 'Content', 'sum', ?, 'docs', 'web_scrape')`, rows[i], rows[i+1])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+func schemaVersion(t *testing.T, path string) int {
+	t.Helper()
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+	return version
+}
+
+func TestMigrateFromOldSchema(t *testing.T) {
+	path := newOldDatabase(t,
+		"https://example.com/a", "[3, 4]",
+		"https://example.com/b", "[1, 0]",
+	)
+
+	st, err := NewStorage(path)
+	if err != nil {
+		t.Fatalf("failed to open old database: %v", err)
+	}
+	defer st.Close()
+
+	if version := schemaVersion(t, path); version != len(migrations) {
+		t.Errorf("schema version is %d, want %d", version, len(migrations))
+	}
+
+	doc, err := st.GetDocument("https://example.com/a", "docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The JSON embedding is converted to binary as is; it predates normalization
+	if want := []float32{3, 4}; !slices.Equal(doc.Embeddings, want) {
+		t.Errorf("embeddings are %v, want %v", doc.Embeddings, want)
+	}
+	if doc.Title != "Title" || doc.Content != "Content" || doc.SourceType != "web_scrape" {
+		t.Errorf("document fields changed: %+v", doc)
+	}
+	if st.EmbeddingsNormalized() {
+		t.Error("store with unnormalized embeddings reported as normalized")
+	}
+
+	// Migration 8 seeds the dimension from the stored embeddings
+	dimension, err := st.EmbeddingDimension()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dimension != 2 {
+		t.Errorf("embedding dimension is %d, want 2", dimension)
+	}
+
+	// Every later column can be written and read back
+	doc.Language, doc.Tags = "en", []string{"v2"}
+	if err := st.UpsertDocument(doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc, err = st.GetDocument("https://example.com/a", "docs"); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Language != "en" || !slices.Equal(doc.Tags, []string{"v2"}) {
+		t.Errorf("got language %q and tags %v after upsert", doc.Language, doc.Tags)
+	}
+}
+
+func TestMigrateKeepsUndecodableEmbeddings(t *testing.T) {
+	path := newOldDatabase(t,
+		"https://example.com/good", "[1, 0]",
+		"https://example.com/broken", "[1, 0",
+	)
+
+	_, err := NewStorage(path)
+	if err == nil || !strings.Contains(err.Error(), "https://example.com/broken") {
+		t.Fatalf("NewStorage = %v, want an error naming the broken document", err)
+	}
+
+	// The migration is rolled back, so the rows are untouched
+	if version := schemaVersion(t, path); version != 0 {
+		t.Errorf("schema version is %d after a failed migration, want 0", version)
+	}
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var raw string
+	if err := db.QueryRow("SELECT embeddings FROM documents WHERE url = ?", "https://example.com/broken").Scan(&raw); err != nil {
+		t.Fatal(err)
+	}
+	if raw != "[1, 0" {
+		t.Errorf("broken embeddings changed to %q", raw)
+	}
+}
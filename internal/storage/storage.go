@@ -1,13 +1,20 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/tesh254/pons/internal/vector"
 )
 
 // Document represents the data to be stored.
@@ -15,16 +22,62 @@ type Document struct {
 	URL         string    `json:"url"`
 	Title       string    `json:"title"`
 	Description string    `json:"description"`
-	Content     string    `json:"content"`
+	Content     string    `json:"content,omitempty"`
 	Checksum    string    `json:"checksum"`
-	Embeddings  []float32 `json:"embeddings"`
+	Embeddings  []float32 `json:"embeddings,omitempty"`
 	Context     string    `json:"context"`
 	SourceType  string    `json:"source_type"`
+	// Anchor is the id of the page section this document was cut from, if any
+	Anchor string `json:"anchor"`
+	// Language is the primary language subtag of the content, e.g. "en", if known
+	Language string `json:"language"`
+	// ETag and LastModified are the HTTP validators the page was served with, if any
+	ETag         string `json:"etag"`
+	LastModified string `json:"last_modified"`
+	// Depth is the crawl depth the page was found at (0 for the starting URL and for files)
+	Depth int `json:"depth"`
+	// EmbeddingModel identifies the model that produced Embeddings, if known
+	EmbeddingModel string `json:"embedding_model"`
+	// EmbeddingNorm is the length the embedding had before it was normalized for storage,
+	// 0 if unknown. Embeddings read from the store have unit length; see RawEmbeddings.
+	EmbeddingNorm float64 `json:"embedding_norm,omitempty"`
+	// SeedURL is the URL the crawl that found this document started from, empty for files
+	SeedURL string `json:"seed_url"`
+	// FetchURL is the URL the crawl fetched the page from, empty for files. It differs
+	// from the page URL when the page declared another canonical URL.
+	FetchURL string `json:"fetch_url,omitempty"`
+	// Tags are free-form labels, e.g. "v2" or "deprecated"
+	Tags []string `json:"tags"`
+}
+
+// DeepLink returns the URL to cite for the document: the page URL, pointing at
+// the document's section when it has an anchor.
+func (d *Document) DeepLink() string {
+	page, _, _ := strings.Cut(d.URL, "#")
+	if d.Anchor == "" {
+		return page
+	}
+	return page + "#" + d.Anchor
+}
+
+// RawEmbeddings returns the embedding as the model produced it, restoring the length it
+// had before it was normalized for storage. Documents stored without a known length
+// return their unit-length embedding.
+func (d *Document) RawEmbeddings() []float32 {
+	if d.EmbeddingNorm <= 0 {
+		return d.Embeddings
+	}
+	return vector.Scale(d.Embeddings, d.EmbeddingNorm)
 }
 
 // Storage manages the SQLite database.
 type Storage struct {
 	db *sql.DB
+	// normalized is set while every stored embedding has unit length
+	normalized atomic.Bool
+	// versionConn is the connection DataVersion asks, held open so its answers compare
+	versionMu   sync.Mutex
+	versionConn *sql.Conn
 }
 
 // NewStorage creates or opens an SQLite database.
@@ -47,32 +100,58 @@ func NewStorage(dbPath string) (*Storage, error) {
 		return nil, fmt.Errorf("failed to enable WAL mode: %v", err)
 	}
 
-	// Create documents table if it doesn't exist
-	createTableSQL := `
-	CREATE TABLE IF NOT EXISTS documents (
-		url TEXT PRIMARY KEY,
-		title TEXT,
-		description TEXT,
-		content TEXT,
-		checksum TEXT,
-		embeddings BLOB,
-		context TEXT,
-		source_type TEXT
-	);`
-	_, err = db.Exec(createTableSQL)
-	if err != nil {
+	// Create or upgrade the schema
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	s := &Storage{db: db}
+	var normalized string
+	err = db.QueryRow("SELECT value FROM metadata WHERE key = ?", embeddingsNormalizedKey).Scan(&normalized)
+	if err != nil && err != sql.ErrNoRows {
 		db.Close()
-		return nil, fmt.Errorf("failed to create documents table: %v", err)
+		return nil, fmt.Errorf("failed to read embedding normalization: %v", err)
 	}
+	s.normalized.Store(normalized == "true")
 
-	return &Storage{db: db}, nil
+	return s, nil
 }
 
 // Close closes the database connection.
 func (s *Storage) Close() {
+	s.versionMu.Lock()
+	if s.versionConn != nil {
+		s.versionConn.Close()
+		s.versionConn = nil
+	}
+	s.versionMu.Unlock()
 	s.db.Close()
 }
 
+// DataVersion returns a number that changes whenever a change to the database is
+// committed, whether through this Storage or by another process, so callers can tell
+// when what they loaded from it has gone stale. Only values returned by the same
+// Storage can be compared.
+func (s *Storage) DataVersion() (int64, error) {
+	s.versionMu.Lock()
+	defer s.versionMu.Unlock()
+
+	// SQLite only compares data_version across calls on one connection
+	if s.versionConn == nil {
+		conn, err := s.db.Conn(context.Background())
+		if err != nil {
+			return 0, fmt.Errorf("failed to open connection: %v", err)
+		}
+		s.versionConn = conn
+	}
+	var version int64
+	if err := s.versionConn.QueryRowContext(context.Background(), "PRAGMA data_version").Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read data version: %v", err)
+	}
+	return version, nil
+}
+
 // GetDB returns the underlying *sql.DB connection.
 func (s *Storage) GetDB() *sql.DB {
 	return s.db
@@ -81,29 +160,143 @@ func (s *Storage) GetDB() *sql.DB {
 // UpsertDocument stores a document in the database.
 // The URL is used as the key.
 func (s *Storage) UpsertDocument(doc *Document) error {
-	// Marshal embeddings to JSON for storage in BLOB column
-	embeddingsJSON, err := json.Marshal(doc.Embeddings)
+	return s.UpsertDocuments([]*Document{doc})
+}
+
+// UpsertDocuments stores documents in a single transaction, so either all of them are
+// stored or none are.
+func (s *Storage) UpsertDocuments(docs []*Document) error {
+	tx, err := s.db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to marshal embeddings: %v", err)
+		return fmt.Errorf("failed to start transaction: %v", err)
 	}
+	defer tx.Rollback()
 
-	stmt, err := s.db.Prepare(`
-		INSERT OR REPLACE INTO documents (url, title, description, content, checksum, embeddings, context, source_type)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-	`)
+	stmt, err := tx.Prepare(upsertStatement)
 	if err != nil {
 		return fmt.Errorf("failed to prepare upsert statement: %v", err)
 	}
 	defer stmt.Close()
 
-	_, err = stmt.Exec(doc.URL, doc.Title, doc.Description, doc.Content, doc.Checksum, embeddingsJSON, doc.Context, doc.SourceType)
+	for _, doc := range docs {
+		if err := upsertDocument(tx, stmt, doc); err != nil {
+			return fmt.Errorf("failed to store %s: %v", doc.URL, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit documents: %v", err)
+	}
+	return nil
+}
+
+// upsertStatement stores a document, replacing any document with the same URL.
+const upsertStatement = `
+		INSERT OR REPLACE INTO documents (url, title, description, content, checksum, embeddings, context, source_type, anchor, language, etag, last_modified, depth, embedding_model, seed_url, tags, embedding_norm, fetch_url)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+// upsertDocument executes upsertStatement, prepared on tx, for doc after checking its
+// embedding dimension.
+func upsertDocument(tx *sql.Tx, stmt *sql.Stmt, doc *Document) error {
+	if err := checkDimension(tx, len(doc.Embeddings)); err != nil {
+		return err
+	}
+
+	var tagsJSON interface{}
+	if len(doc.Tags) > 0 {
+		b, err := json.Marshal(doc.Tags)
+		if err != nil {
+			return fmt.Errorf("failed to marshal tags: %v", err)
+		}
+		tagsJSON = string(b)
+	}
+
+	_, err := stmt.Exec(doc.URL, doc.Title, doc.Description, doc.Content, doc.Checksum, MarshalEmbedding(vector.Normalize(doc.Embeddings)), doc.Context, doc.SourceType, doc.Anchor, doc.Language, doc.ETag, doc.LastModified, doc.Depth, doc.EmbeddingModel, doc.SeedURL, tagsJSON, storedNorm(doc), doc.FetchURL)
 	if err != nil {
 		return fmt.Errorf("failed to execute upsert statement: %v", err)
 	}
 	return nil
 }
 
+// storedNorm returns the length recorded for doc's embedding: its own length, unless it
+// already has unit length and carries the length it had before, as exported documents do.
+func storedNorm(doc *Document) float64 {
+	if len(doc.Embeddings) == 0 {
+		return 0
+	}
+	if doc.EmbeddingNorm > 0 && isNormalized(doc.Embeddings) {
+		return doc.EmbeddingNorm
+	}
+	return vector.Norm(doc.Embeddings)
+}
+
+// CountStaleEmbeddings counts documents whose embeddings were not produced by model,
+// optionally filtered by context.
+func (s *Storage) CountStaleEmbeddings(model, context string) (int, error) {
+	query := "SELECT COUNT(*) FROM documents WHERE COALESCE(embedding_model, '') != ?"
+	args := []interface{}{model}
+
+	if context != "" {
+		query += " AND context = ?"
+		args = append(args, context)
+	}
+
+	var count int
+	if err := s.db.QueryRow(query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count stale embeddings: %v", err)
+	}
+	return count, nil
+}
+
+// ListStaleEmbeddings returns up to limit documents ordered by URL whose embeddings were
+// not produced by model, starting after the URL after. Paging by URL rather than offset
+// keeps the pages stable while earlier rows are being updated.
+func (s *Storage) ListStaleEmbeddings(model, context, after string, limit int) ([]*Document, error) {
+	query := "SELECT " + documentColumns + " FROM documents WHERE COALESCE(embedding_model, '') != ? AND url > ?"
+	args := []interface{}{model, after}
+
+	if context != "" {
+		query += " AND context = ?"
+		args = append(args, context)
+	}
+
+	query += " ORDER BY url LIMIT ?"
+	args = append(args, limit)
+
+	docs, err := s.queryDocuments(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stale embeddings: %v", err)
+	}
+	return docs, nil
+}
+
+// UpdateEmbeddings replaces the embeddings and embedding model of the given documents
+// in a single transaction.
+func (s *Storage) UpdateEmbeddings(docs []*Document) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("UPDATE documents SET embeddings = ?, embedding_norm = ?, embedding_model = ? WHERE url = ?")
+	if err != nil {
+		return fmt.Errorf("failed to prepare update statement: %v", err)
+	}
+	defer stmt.Close()
 
+	for _, doc := range docs {
+		if _, err := stmt.Exec(MarshalEmbedding(vector.Normalize(doc.Embeddings)), vector.Norm(doc.Embeddings), doc.EmbeddingModel, doc.URL); err != nil {
+			return fmt.Errorf("failed to update embeddings for %s: %v", doc.URL, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit embeddings: %v", err)
+	}
+	return nil
+}
 
 // DeleteDocumentsByPrefix deletes all documents with a URL starting with the given prefix, optionally filtered by context.
 func (s *Storage) DeleteDocumentsByPrefix(prefix, context string) error {
@@ -128,19 +321,392 @@ func (s *Storage) DeleteDocumentsByPrefix(prefix, context string) error {
 	return nil
 }
 
+// DeleteStaleChunks deletes the rows stored for url, either as the url itself or as its
+// url#chunkN chunks, that are not listed in keep. It returns how many rows were deleted.
+func (s *Storage) DeleteStaleChunks(url, context string, keep []string) (int64, error) {
+	query := "DELETE FROM documents WHERE context = ? AND (url = ? OR url LIKE ? || '#chunk%')"
+	args := []interface{}{context, url, url}
+	if len(keep) > 0 {
+		query += " AND url NOT IN (?" + strings.Repeat(", ?", len(keep)-1) + ")"
+		for _, k := range keep {
+			args = append(args, k)
+		}
+	}
+
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete stale chunks: %v", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete stale chunks: %v", err)
+	}
+	return deleted, nil
+}
+
 // Clean deletes all documents from the database.
 func (s *Storage) Clean() error {
 	_, err := s.db.Exec("DELETE FROM documents")
 	if err != nil {
 		return fmt.Errorf("failed to clean documents table: %v", err)
 	}
+	// An empty store accepts embeddings of any dimension again
+	if _, err := s.db.Exec("DELETE FROM metadata WHERE key = ?", embeddingDimensionKey); err != nil {
+		return fmt.Errorf("failed to reset embedding dimension: %v", err)
+	}
+	// and holds no unnormalized embeddings
+	if _, err := s.db.Exec("INSERT OR REPLACE INTO metadata (key, value) VALUES (?, 'true')", embeddingsNormalizedKey); err != nil {
+		return fmt.Errorf("failed to record embedding normalization: %v", err)
+	}
+	s.normalized.Store(true)
+	return nil
+}
+
+// Optimize reclaims the space left behind by deleted documents, refreshes the query
+// planner statistics and truncates the write-ahead log.
+func (s *Storage) Optimize() error {
+	if _, err := s.db.Exec("VACUUM"); err != nil {
+		return fmt.Errorf("failed to vacuum database: %v", err)
+	}
+	if _, err := s.db.Exec("PRAGMA optimize"); err != nil {
+		return fmt.Errorf("failed to optimize database: %v", err)
+	}
+	// VACUUM goes through the WAL, so checkpoint last
+	if _, err := s.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return fmt.Errorf("failed to checkpoint WAL: %v", err)
+	}
+	return nil
+}
+
+// Ping checks that the database can still be queried.
+func (s *Storage) Ping() error {
+	var one int
+	if err := s.db.QueryRow("SELECT 1 FROM documents LIMIT 1").Scan(&one); err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to query database: %v", err)
+	}
+	return nil
+}
+
+// DeleteByContext deletes every document in a context and returns how many were deleted.
+func (s *Storage) DeleteByContext(context string) (int, error) {
+	result, err := s.db.Exec("DELETE FROM documents WHERE context = ?", context)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete context %s: %v", context, err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count deleted documents: %v", err)
+	}
+	return int(deleted), nil
+}
+
+// RenameContext moves every document in oldContext to newContext.
+func (s *Storage) RenameContext(oldContext, newContext string) error {
+	if _, err := s.db.Exec("UPDATE documents SET context = ? WHERE context = ?", newContext, oldContext); err != nil {
+		return fmt.Errorf("failed to rename context %s: %v", oldContext, err)
+	}
+	return nil
+}
+
+// embeddingsNormalizedKey is the metadata key set to "true" once every stored embedding
+// has unit length. Embeddings are normalized on write, but databases created before that
+// may still hold unnormalized ones until NormalizeEmbeddings runs.
+const embeddingsNormalizedKey = "embeddings_normalized"
+
+// EmbeddingsNormalized reports whether every stored embedding has unit length, in which
+// case the dot product of a normalized query with a stored embedding is their cosine
+// similarity.
+func (s *Storage) EmbeddingsNormalized() bool {
+	return s.normalized.Load()
+}
+
+// NormalizeEmbeddings rescales every stored embedding to unit length, keeping its former
+// length as the embedding norm, and records that the store is normalized. It returns how
+// many embeddings were rewritten.
+func (s *Storage) NormalizeEmbeddings() (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	type update struct {
+		url        string
+		embeddings []byte
+		norm       float64
+	}
+	var updates []update
+	rows, err := tx.Query("SELECT url, embeddings FROM documents WHERE embeddings IS NOT NULL")
+	if err != nil {
+		return 0, fmt.Errorf("failed to query embeddings: %v", err)
+	}
+	for rows.Next() {
+		var url string
+		var raw []byte
+		if err := rows.Scan(&url, &raw); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan embeddings: %v", err)
+		}
+		embeddings, err := UnmarshalEmbedding(raw)
+		if err != nil || isNormalized(embeddings) {
+			continue
+		}
+		updates = append(updates, update{url: url, embeddings: MarshalEmbedding(vector.Normalize(embeddings)), norm: vector.Norm(embeddings)})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to iterate embeddings: %v", err)
+	}
+
+	for _, u := range updates {
+		if _, err := tx.Exec("UPDATE documents SET embeddings = ?, embedding_norm = ? WHERE url = ?", u.embeddings, u.norm, u.url); err != nil {
+			return 0, fmt.Errorf("failed to update embeddings for %s: %v", u.url, err)
+		}
+	}
+	if _, err := tx.Exec("INSERT OR REPLACE INTO metadata (key, value) VALUES (?, 'true')", embeddingsNormalizedKey); err != nil {
+		return 0, fmt.Errorf("failed to record embedding normalization: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit embeddings: %v", err)
+	}
+	s.normalized.Store(true)
+	return len(updates), nil
+}
+
+// isNormalized reports whether v is empty, zero or already has unit length.
+func isNormalized(v []float32) bool {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	return sum == 0 || math.Abs(sum-1) < 1e-5
+}
+
+// embeddingDimensionKey is the metadata key holding the dimension every stored embedding must have.
+const embeddingDimensionKey = "embedding_dimension"
+
+// checkDimension verifies that an embedding of the given dimension matches the store's.
+// The first embedding stored sets the dimension; documents without embeddings are not checked.
+func checkDimension(tx *sql.Tx, dimension int) error {
+	if dimension == 0 {
+		return nil
+	}
+
+	var expected int
+	err := tx.QueryRow("SELECT value FROM metadata WHERE key = ?", embeddingDimensionKey).Scan(&expected)
+	if err == sql.ErrNoRows {
+		if _, err := tx.Exec("INSERT INTO metadata (key, value) VALUES (?, ?)", embeddingDimensionKey, dimension); err != nil {
+			return fmt.Errorf("failed to record embedding dimension: %v", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read embedding dimension: %v", err)
+	}
+
+	if dimension != expected {
+		return fmt.Errorf("embedding dimension mismatch: this database holds %d-dimensional embeddings but got %d; the embedding model has likely changed, so run `pons clean` or use a new --db", expected, dimension)
+	}
+	return nil
+}
+
+// EmbeddingDimension returns the dimension every stored embedding must have, or 0 if none has been stored yet.
+func (s *Storage) EmbeddingDimension() (int, error) {
+	var dimension int
+	err := s.db.QueryRow("SELECT value FROM metadata WHERE key = ?", embeddingDimensionKey).Scan(&dimension)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read embedding dimension: %v", err)
+	}
+	return dimension, nil
+}
+
+// SetEmbeddingDimension replaces the dimension every stored embedding must have, after
+// all embeddings have been regenerated with a new model.
+func (s *Storage) SetEmbeddingDimension(dimension int) error {
+	if _, err := s.db.Exec("INSERT OR REPLACE INTO metadata (key, value) VALUES (?, ?)", embeddingDimensionKey, dimension); err != nil {
+		return fmt.Errorf("failed to record embedding dimension: %v", err)
+	}
+	return nil
+}
+
+// DimensionCount is how many documents in a context have embeddings of one dimension.
+type DimensionCount struct {
+	Context   string
+	Dimension int
+	Documents int
+}
+
+// EmbeddingDimensions counts stored embeddings by context and dimension.
+// Documents without embeddings are left out.
+func (s *Storage) EmbeddingDimensions() ([]DimensionCount, error) {
+	rows, err := s.db.Query(`
+		SELECT COALESCE(context, ''), LENGTH(embeddings) / ? AS dimension, COUNT(*)
+		FROM documents
+		WHERE embeddings IS NOT NULL
+		GROUP BY 1, 2
+		HAVING dimension > 0
+		ORDER BY 1, 2`, embeddingComponentSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count embedding dimensions: %v", err)
+	}
+	defer rows.Close()
+
+	var counts []DimensionCount
+	for rows.Next() {
+		var c DimensionCount
+		if err := rows.Scan(&c.Context, &c.Dimension, &c.Documents); err != nil {
+			return nil, fmt.Errorf("failed to scan dimension count: %v", err)
+		}
+		counts = append(counts, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating rows: %v", err)
+	}
+	return counts, nil
+}
+
+// ContextStats summarizes the documents stored in one context.
+type ContextStats struct {
+	Context      string `json:"context"`
+	Documents    int    `json:"documents"`
+	ContentBytes int64  `json:"content_bytes"`
+	// Dimension is the most common embedding dimension in the context, 0 if none have embeddings
+	Dimension int `json:"dimension"`
+}
+
+// Stats summarizes the whole store, per context and in total.
+type Stats struct {
+	Contexts     []ContextStats `json:"contexts"`
+	Documents    int            `json:"documents"`
+	ContentBytes int64          `json:"content_bytes"`
+	// Dimension is the embedding dimension the store enforces, 0 before the first embedding is stored
+	Dimension int `json:"dimension"`
+}
+
+// Stats counts documents and content bytes per context, with each context's embedding dimension.
+func (s *Storage) Stats() (*Stats, error) {
+	rows, err := s.db.Query(`
+		SELECT COALESCE(context, ''), COUNT(*), COALESCE(SUM(LENGTH(CAST(content AS BLOB))), 0)
+		FROM documents
+		GROUP BY 1
+		ORDER BY 1`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect stats: %v", err)
+	}
+	defer rows.Close()
+
+	stats := &Stats{}
+	for rows.Next() {
+		var c ContextStats
+		if err := rows.Scan(&c.Context, &c.Documents, &c.ContentBytes); err != nil {
+			return nil, fmt.Errorf("failed to scan stats row: %v", err)
+		}
+		stats.Contexts = append(stats.Contexts, c)
+		stats.Documents += c.Documents
+		stats.ContentBytes += c.ContentBytes
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error after iterating rows: %v", err)
+	}
+
+	counts, err := s.EmbeddingDimensions()
+	if err != nil {
+		return nil, err
+	}
+	for i := range stats.Contexts {
+		c := &stats.Contexts[i]
+		most := 0
+		for _, count := range counts {
+			if count.Context == c.Context && count.Documents > most {
+				c.Dimension, most = count.Dimension, count.Documents
+			}
+		}
+	}
+
+	if stats.Dimension, err = s.EmbeddingDimension(); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// GetChecksum returns the checksum of the document stored under url in context.
+// A missing document yields an empty checksum, which never matches a real one.
+func (s *Storage) GetChecksum(url, context string) (string, error) {
+	var checksum string
+	err := s.db.QueryRow("SELECT COALESCE(checksum, '') FROM documents WHERE url = ? AND context = ?", url, context).Scan(&checksum)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get checksum: %v", err)
+	}
+	return checksum, nil
+}
+
+// GetValidators returns the ETag and Last-Modified values stored for a page URL.
+// Section documents stored as url#anchor count as the page, and so do documents
+// fetched from url but stored under the page's canonical URL. Both values are empty
+// when the page is unknown or was stored without validators.
+func (s *Storage) GetValidators(url string) (etag, lastModified string, err error) {
+	err = s.db.QueryRow(`
+		SELECT COALESCE(etag, ''), COALESCE(last_modified, '') FROM documents
+		WHERE url = ? OR url LIKE ? || '#%' OR fetch_url = ?
+		ORDER BY url LIMIT 1`, url, url, url).Scan(&etag, &lastModified)
+	if err == sql.ErrNoRows {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get validators: %v", err)
+	}
+	return etag, lastModified, nil
+}
+
+// SetFetchURL records fetchURL as the URL the documents of the page stored under url,
+// including its url#anchor sections, were fetched from.
+func (s *Storage) SetFetchURL(url, context, fetchURL string) error {
+	_, err := s.db.Exec(`
+		UPDATE documents SET fetch_url = ?
+		WHERE context = ? AND (url = ? OR url LIKE ? || '#%') AND COALESCE(fetch_url, '') != ?`,
+		fetchURL, context, url, url, fetchURL)
+	if err != nil {
+		return fmt.Errorf("failed to set fetch URL of %s: %v", url, err)
+	}
 	return nil
 }
 
+// ftsTable is the name of the optional FTS5 full-text index over documents.
+const ftsTable = "documents_fts"
+
+// RebuildIndexes rebuilds every SQLite index on the documents table, and the
+// full-text index if the database has one, from the stored rows.
+func (s *Storage) RebuildIndexes() error {
+	if _, err := s.db.Exec("REINDEX"); err != nil {
+		return fmt.Errorf("failed to rebuild indexes: %v", err)
+	}
+
+	var name string
+	err := s.db.QueryRow("SELECT name FROM sqlite_master WHERE type = 'table' AND name = ?", ftsTable).Scan(&name)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up full-text index: %v", err)
+	}
+	if _, err := s.db.Exec("INSERT INTO " + ftsTable + "(" + ftsTable + ") VALUES('rebuild')"); err != nil {
+		return fmt.Errorf("failed to rebuild full-text index: %v", err)
+	}
+	return nil
+}
+
+// ErrNotFound is returned by GetDocument when no document is stored under the URL.
+var ErrNotFound = errors.New("document not found")
+
 // GetDocument retrieves a document by its URL, optionally filtered by context.
 func (s *Storage) GetDocument(url, context string) (*Document, error) {
-	var row *sql.Row
-	query := "SELECT url, title, description, content, checksum, embeddings, context, source_type FROM documents WHERE url = ?"
+	query := "SELECT " + documentColumns + " FROM documents WHERE url = ?"
 	args := []interface{}{url}
 
 	if context != "" {
@@ -148,160 +714,289 @@ func (s *Storage) GetDocument(url, context string) (*Document, error) {
 		args = append(args, context)
 	}
 
-	row = s.db.QueryRow(query, args...)
-
-	var doc Document
-	var embeddingsJSON []byte
-	err := row.Scan(&doc.URL, &doc.Title, &doc.Description, &doc.Content, &doc.Checksum, &embeddingsJSON, &doc.Context, &doc.SourceType)
+	doc, err := scanDocument(s.db.QueryRow(query, args...))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("document not found")
+			return nil, ErrNotFound
 		}
 		return nil, fmt.Errorf("failed to scan document: %v", err)
 	}
 
-	// Unmarshal embeddings from JSON
-	if err := json.Unmarshal(embeddingsJSON, &doc.Embeddings); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal embeddings: %v", err)
+	return doc, nil
+}
+
+// DeleteDocumentsBySeed deletes every document stored by crawls started from seed,
+// optionally filtered by context, and returns how many were deleted.
+func (s *Storage) DeleteDocumentsBySeed(seed, context string) (int64, error) {
+	query := "DELETE FROM documents WHERE seed_url = ?"
+	args := []interface{}{seed}
+
+	if context != "" {
+		query += " AND context = ?"
+		args = append(args, context)
 	}
 
-	return &doc, nil
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete documents for seed %s: %v", seed, err)
+	}
+	return result.RowsAffected()
+}
+
+// CountDocuments returns the number of stored documents that pass the filter.
+func (s *Storage) CountDocuments(filter SearchFilter) (int, error) {
+	where, args := filter.where()
+	query := "SELECT COUNT(*) FROM documents" + where
+
+	var count int
+	if err := s.db.QueryRow(query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count documents: %v", err)
+	}
+	return count, nil
 }
 
-// ListDocuments retrieves documents from the store, optionally filtered by context, with a limit.
-func (s *Storage) ListDocuments(context string, limit int) ([]*Document, error) {
-	var rows *sql.Rows
-	var err error
+// ListDocuments retrieves one page of documents ordered by URL that pass the filter.
+func (s *Storage) ListDocuments(filter SearchFilter, limit, offset int) ([]*Document, error) {
+	where, args := filter.where()
+	query := "SELECT " + documentColumns + " FROM documents" + where
+
+	query += " ORDER BY url LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	docs, err := s.queryDocuments(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query documents: %v", err)
+	}
+	return docs, nil
+}
 
-	query := "SELECT url, title, description, content, checksum, embeddings, context, source_type FROM documents"
+// ListAllDocuments retrieves all documents ordered by URL, optionally filtered by context.
+func (s *Storage) ListAllDocuments(context string) ([]*Document, error) {
+	query := "SELECT " + documentColumns + " FROM documents"
 	args := []interface{}{}
 
 	if context != "" {
 		query += " WHERE context = ?"
 		args = append(args, context)
 	}
+	query += " ORDER BY url"
 
-	query += " LIMIT ?"
-	args = append(args, limit)
-
-	rows, err = s.db.Query(query, args...)
+	docs, err := s.queryDocuments(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query documents: %v", err)
 	}
-	defer rows.Close()
+	return docs, nil
+}
 
-	var docs []*Document
-	for rows.Next() {
-		var doc Document
-		var embeddingsJSON []byte
-		if err := rows.Scan(&doc.URL, &doc.Title, &doc.Description, &doc.Content, &doc.Checksum, &embeddingsJSON, &doc.Context, &doc.SourceType); err != nil {
-			return nil, fmt.Errorf("failed to scan document row: %v", err)
-		}
+// SearchFilter restricts which documents a search considers. Empty fields match everything.
+type SearchFilter struct {
+	Context  string
+	Language string
+	SeedURL  string
+	// SourceType is "web_scrape", "file_read" or "pdf"
+	SourceType string
+	// Tags only matches documents carrying every listed tag
+	Tags []string
+}
 
-		// Unmarshal embeddings from JSON
-		if err := json.Unmarshal(embeddingsJSON, &doc.Embeddings); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal embeddings: %v", err)
+// Matches reports whether a document passes the filter.
+func (f SearchFilter) Matches(doc *Document) bool {
+	return (f.Context == "" || doc.Context == f.Context) &&
+		(f.Language == "" || doc.Language == f.Language) &&
+		(f.SeedURL == "" || doc.SeedURL == f.SeedURL) &&
+		(f.SourceType == "" || doc.SourceType == f.SourceType) &&
+		hasTags(doc.Tags, f.Tags)
+}
+
+// hasTags reports whether tags contains every wanted tag.
+func hasTags(tags, wanted []string) bool {
+	for _, w := range wanted {
+		found := false
+		for _, t := range tags {
+			if t == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
 		}
-		docs = append(docs, &doc)
 	}
+	return true
+}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error after iterating rows: %v", err)
+// where builds the SQL WHERE clause and arguments for the filter.
+func (f SearchFilter) where() (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	if f.Context != "" {
+		conditions = append(conditions, "context = ?")
+		args = append(args, f.Context)
+	}
+	if f.Language != "" {
+		conditions = append(conditions, "language = ?")
+		args = append(args, f.Language)
+	}
+	if f.SeedURL != "" {
+		conditions = append(conditions, "seed_url = ?")
+		args = append(args, f.SeedURL)
+	}
+	if f.SourceType != "" {
+		conditions = append(conditions, "source_type = ?")
+		args = append(args, f.SourceType)
+	}
+	for _, tag := range f.Tags {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM json_each(documents.tags) WHERE value = ?)")
+		args = append(args, tag)
 	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
 
+// SearchDocChunks searches for documents based on a query and a filter, ordered by URL.
+func (s *Storage) SearchDocChunks(query string, filter SearchFilter) ([]*Document, error) {
+	// This is a placeholder. Actual implementation will involve vector search
+	// and filtering. For now, it will just return all documents that match the filter.
+	where, args := filter.where()
+	baseQuery := "SELECT " + documentColumns + " FROM documents" + where + " ORDER BY url"
+
+	// For now, without actual vector search, we'll just return all documents
+	// that match the context. In a real scenario, the 'query' would be used
+	// to perform a similarity search on the 'embeddings' field.
+	docs, err := s.queryDocuments(baseQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query documents for search: %v", err)
+	}
 	return docs, nil
 }
 
-// ListAllDocuments retrieves all documents from the store, optionally filtered by context.
-func (s *Storage) ListAllDocuments(context string) ([]*Document, error) {
-	var rows *sql.Rows
-	var err error
-
-	query := "SELECT url, title, description, content, checksum, embeddings, context, source_type FROM documents"
+// EachDocument streams documents ordered by URL, optionally filtered by context, calling fn for each.
+// Rows are read one at a time so large stores are never loaded into memory at once.
+// Iteration stops at the first error returned by fn.
+func (s *Storage) EachDocument(context string, fn func(*Document) error) error {
+	query := "SELECT " + documentColumns + " FROM documents"
 	args := []interface{}{}
 
 	if context != "" {
 		query += " WHERE context = ?"
 		args = append(args, context)
 	}
+	query += " ORDER BY url"
 
-	rows, err = s.db.Query(query, args...)
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query documents: %v", err)
+		return fmt.Errorf("failed to query documents: %v", err)
 	}
 	defer rows.Close()
 
-	var docs []*Document
 	for rows.Next() {
-		var doc Document
-		var embeddingsJSON []byte
-		if err := rows.Scan(&doc.URL, &doc.Title, &doc.Description, &doc.Content, &doc.Checksum, &embeddingsJSON, &doc.Context, &doc.SourceType); err != nil {
-			return nil, fmt.Errorf("failed to scan document row: %v", err)
+		doc, err := scanDocument(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan document row: %v", err)
 		}
-
-		// Unmarshal embeddings from JSON
-		if err := json.Unmarshal(embeddingsJSON, &doc.Embeddings); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal embeddings: %v", err)
+		if err := fn(doc); err != nil {
+			return err
 		}
-		docs = append(docs, &doc)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error after iterating rows: %v", err)
+		return fmt.Errorf("error after iterating rows: %v", err)
+	}
+	return nil
+}
+
+// IncompleteCriteria selects which optional metadata fields ListIncomplete checks.
+type IncompleteCriteria struct {
+	Title       bool
+	Description bool
+	Context     bool
+}
+
+// ListIncomplete retrieves documents, ordered by URL, where any of the fields selected by criteria are empty.
+// If no fields are selected, all optional fields are checked.
+func (s *Storage) ListIncomplete(criteria IncompleteCriteria) ([]*Document, error) {
+	if !criteria.Title && !criteria.Description && !criteria.Context {
+		criteria = IncompleteCriteria{Title: true, Description: true, Context: true}
 	}
 
+	var conditions []string
+	if criteria.Title {
+		conditions = append(conditions, "title IS NULL OR title = ''")
+	}
+	if criteria.Description {
+		conditions = append(conditions, "description IS NULL OR description = ''")
+	}
+	if criteria.Context {
+		conditions = append(conditions, "context IS NULL OR context = ''")
+	}
+
+	query := "SELECT " + documentColumns + " FROM documents WHERE (" + strings.Join(conditions, ") OR (") + ") ORDER BY url"
+
+	docs, err := s.queryDocuments(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query incomplete documents: %v", err)
+	}
 	return docs, nil
 }
 
-// SearchDocChunks searches for documents based on a query and optional context.
-func (s *Storage) SearchDocChunks(query string, context string) ([]*Document, error) {
-	// This is a placeholder. Actual implementation will involve vector search
-	// and filtering by context. For now, it will just return all documents
-	// that match the context (if provided).
-	var rows *sql.Rows
-	var err error
+// documentColumns lists the columns read into a Document, in scan order.
+const documentColumns = "url, title, description, content, checksum, embeddings, context, source_type, COALESCE(anchor, ''), COALESCE(language, ''), COALESCE(etag, ''), COALESCE(last_modified, ''), COALESCE(depth, 0), COALESCE(embedding_model, ''), COALESCE(seed_url, ''), COALESCE(tags, '[]'), COALESCE(embedding_norm, 0), COALESCE(fetch_url, '')"
 
-	baseQuery := "SELECT url, title, description, content, checksum, embeddings, context, source_type FROM documents"
-	args := []interface{}{}
+// rowScanner is implemented by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
 
-	if context != "" {
-		baseQuery += " WHERE context = ?"
-		args = append(args, context)
+// scanDocument scans a single row selected with documentColumns into a Document.
+func scanDocument(row rowScanner) (*Document, error) {
+	var doc Document
+	var embeddings []byte
+	var tagsJSON string
+	if err := row.Scan(&doc.URL, &doc.Title, &doc.Description, &doc.Content, &doc.Checksum, &embeddings, &doc.Context, &doc.SourceType, &doc.Anchor, &doc.Language, &doc.ETag, &doc.LastModified, &doc.Depth, &doc.EmbeddingModel, &doc.SeedURL, &tagsJSON, &doc.EmbeddingNorm, &doc.FetchURL); err != nil {
+		return nil, err
 	}
 
-	// For now, without actual vector search, we'll just return all documents
-	// that match the context. In a real scenario, the 'query' would be used
-	// to perform a similarity search on the 'embeddings' field.
-	rows, err = s.db.Query(baseQuery, args...)
+	if err := json.Unmarshal([]byte(tagsJSON), &doc.Tags); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal tags: %v", err)
+	}
+
+	embedding, err := UnmarshalEmbedding(embeddings)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query documents for search: %v", err)
+		return nil, fmt.Errorf("failed to unmarshal embeddings: %v", err)
+	}
+	doc.Embeddings = embedding
+	return &doc, nil
+}
+
+// queryDocuments runs a query selecting documentColumns and scans every resulting row.
+func (s *Storage) queryDocuments(query string, args ...interface{}) ([]*Document, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
 	}
 	defer rows.Close()
 
 	var docs []*Document
 	for rows.Next() {
-		var doc Document
-		var embeddingsJSON []byte
-		if err := rows.Scan(&doc.URL, &doc.Title, &doc.Description, &doc.Content, &doc.Checksum, &embeddingsJSON, &doc.Context, &doc.SourceType); err != nil {
-			return nil, fmt.Errorf("failed to scan document row during search: %v", err)
-		}
-
-		if err := json.Unmarshal(embeddingsJSON, &doc.Embeddings); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal embeddings during search: %v", err)
+		doc, err := scanDocument(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan document row: %v", err)
 		}
-		docs = append(docs, &doc)
+		docs = append(docs, doc)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error after iterating rows during search: %v", err)
+		return nil, fmt.Errorf("error after iterating rows: %v", err)
 	}
 
 	return docs, nil
 }
 
-// GetContexts retrieves a list of unique contexts from the database.
+// GetContexts retrieves the unique contexts from the database in alphabetical order.
 func (s *Storage) GetContexts() ([]string, error) {
-	rows, err := s.db.Query("SELECT DISTINCT context FROM documents WHERE context IS NOT NULL AND context != ''")
+	rows, err := s.db.Query("SELECT DISTINCT context FROM documents WHERE context IS NOT NULL AND context != '' ORDER BY context")
 	if err != nil {
 		return nil, fmt.Errorf("failed to query distinct contexts: %v", err)
 	}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestGetValidatorsByFetchURL(t *testing.T) {
+	st, err := NewStorage(filepath.Join(t.TempDir(), "pons.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	// A page fetched from /docs/?ref=nav that declared /docs as its canonical URL
+	err = st.UpsertDocument(&Document{
+		URL:          "https://example.com/docs#chunk0",
+		Content:      "Docs",
+		Context:      "docs",
+		SourceType:   "web_scrape",
+		ETag:         `"v1"`,
+		LastModified: "Mon, 02 Jan 2006 15:04:05 GMT",
+		FetchURL:     "https://example.com/docs/?ref=nav",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, url := range []string{"https://example.com/docs", "https://example.com/docs/?ref=nav"} {
+		etag, lastModified, err := st.GetValidators(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if etag != `"v1"` || lastModified == "" {
+			t.Errorf("GetValidators(%s) = %q, %q, want the stored validators", url, etag, lastModified)
+		}
+	}
+	if etag, _, err := st.GetValidators("https://example.com/blog"); err != nil || etag != "" {
+		t.Errorf("GetValidators of an unknown page = %q, %v, want no validators", etag, err)
+	}
+}
+
+func TestSetFetchURLRecordsExistingDocuments(t *testing.T) {
+	st, err := NewStorage(filepath.Join(t.TempDir(), "pons.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	// Stored before fetch URLs were recorded
+	for _, url := range []string{"https://example.com/docs", "https://example.com/docs#install", "https://example.com/docs-old"} {
+		if err := st.UpsertDocument(&Document{URL: url, Content: "Docs", Context: "docs", ETag: `"v1"`}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := st.SetFetchURL("https://example.com/docs", "docs", "https://example.com/docs/"); err != nil {
+		t.Fatal(err)
+	}
+
+	for url, want := range map[string]string{
+		"https://example.com/docs":         "https://example.com/docs/",
+		"https://example.com/docs#install": "https://example.com/docs/",
+		"https://example.com/docs-old":     "",
+	} {
+		doc, err := st.GetDocument(url, "docs")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if doc.FetchURL != want {
+			t.Errorf("fetch URL of %s is %q, want %q", url, doc.FetchURL, want)
+		}
+	}
+	if etag, _, _ := st.GetValidators("https://example.com/docs/"); etag != `"v1"` {
+		t.Errorf("GetValidators by fetch URL = %q, want %q", etag, `"v1"`)
+	}
+}
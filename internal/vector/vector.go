@@ -0,0 +1,139 @@
+package vector
+
+import (
+	"fmt"
+	"math"
+)
+
+// Metric selects how the similarity of two embeddings is scored. Higher scores always
+// mean more similar. The zero value is Cosine.
+type Metric int
+
+const (
+	// Cosine scores the cosine of the angle between two vectors, from -1 to 1
+	Cosine Metric = iota
+	// Dot scores the dot product, which also weighs in the vectors' lengths
+	Dot
+	// Euclidean scores the negative Euclidean (L2) distance, so identical vectors score 0
+	// and every other pair scores below it
+	Euclidean
+)
+
+// String returns the metric's name as accepted by ParseMetric.
+func (m Metric) String() string {
+	switch m {
+	case Cosine:
+		return "cosine"
+	case Dot:
+		return "dot"
+	case Euclidean:
+		return "euclidean"
+	default:
+		return fmt.Sprintf("Metric(%d)", int(m))
+	}
+}
+
+// ParseMetric returns the metric named "cosine", "dot" or "euclidean". An empty name
+// selects Cosine.
+func ParseMetric(name string) (Metric, error) {
+	switch name {
+	case "", "cosine":
+		return Cosine, nil
+	case "dot":
+		return Dot, nil
+	case "euclidean":
+		return Euclidean, nil
+	default:
+		return Cosine, fmt.Errorf("unknown similarity metric %q (use cosine, dot or euclidean)", name)
+	}
+}
+
+// Similarity scores a and b with the metric. The vectors must have the same length.
+func (m Metric) Similarity(a, b []float32) (float64, error) {
+	switch m {
+	case Dot:
+		return DotProduct(a, b)
+	case Euclidean:
+		distance, err := EuclideanDistance(a, b)
+		return -distance, err
+	default:
+		return CosineSimilarity(a, b)
+	}
+}
+
+// CosineSimilarity computes the cosine similarity between two vectors. It is 0 when
+// either vector is zero.
+func CosineSimilarity(a, b []float32) (float64, error) {
+	if len(a) != len(b) {
+		return 0, fmt.Errorf("vectors must have the same length")
+	}
+
+	var dotProduct, aMagnitude, bMagnitude float64
+	for i := range a {
+		dotProduct += float64(a[i]) * float64(b[i])
+		aMagnitude += float64(a[i]) * float64(a[i])
+		bMagnitude += float64(b[i]) * float64(b[i])
+	}
+
+	if aMagnitude == 0 || bMagnitude == 0 {
+		return 0, nil
+	}
+
+	return dotProduct / (math.Sqrt(aMagnitude) * math.Sqrt(bMagnitude)), nil
+}
+
+// DotProduct computes the dot product of two vectors, which is their cosine similarity
+// when both have unit length.
+func DotProduct(a, b []float32) (float64, error) {
+	if len(a) != len(b) {
+		return 0, fmt.Errorf("vectors must have the same length")
+	}
+
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum, nil
+}
+
+// EuclideanDistance computes the Euclidean (L2) distance between two vectors.
+func EuclideanDistance(a, b []float32) (float64, error) {
+	if len(a) != len(b) {
+		return 0, fmt.Errorf("vectors must have the same length")
+	}
+
+	var sum float64
+	for i := range a {
+		d := float64(a[i]) - float64(b[i])
+		sum += d * d
+	}
+	return math.Sqrt(sum), nil
+}
+
+// Norm computes the Euclidean length of a vector.
+func Norm(v []float32) float64 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	return math.Sqrt(sum)
+}
+
+// Normalize returns a copy of v scaled to unit length. Zero and empty vectors are
+// returned unchanged.
+func Normalize(v []float32) []float32 {
+	norm := Norm(v)
+	if norm == 0 {
+		return v
+	}
+	return Scale(v, 1/norm)
+}
+
+// Scale returns a copy of v with every component multiplied by factor.
+func Scale(v []float32, factor float64) []float32 {
+	out := make([]float32, len(v))
+	for i, x := range v {
+		out[i] = float32(float64(x) * factor)
+	}
+	return out
+}
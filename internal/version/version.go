@@ -1,6 +1,7 @@
 package version
 
 import (
+	"encoding/json"
 	"fmt"
 	"runtime"
 	"runtime/debug"
@@ -175,32 +176,18 @@ func GetDetailedVersion() string {
 	return strings.Join(parts, "\n")
 }
 
-// GetJSONVersion returns version info in JSON format
+// GetJSONVersion returns version info in JSON format.
+//
+// The output is the full BuildInfo struct, so its field names follow the
+// struct's json tags and stay stable for tooling that parses them.
 func GetJSONVersion() string {
 	info := GetBuildInfo()
 
-	return fmt.Sprintf(`{
-  "version": "%s",
-  "git_commit": "%s",
-  "git_branch": "%s", 
-  "git_tag": "%s",
-  "build_date": "%s",
-  "go_version": "%s",
-  "platform": "%s",
-  "compiler": "%s",
-  "is_modified": %t,
-  "module_path": "%s"
-}`,
-		info.Version,
-		info.GitCommit,
-		info.GitBranch,
-		info.GitTag,
-		info.BuildDate,
-		info.GoVersion,
-		info.Platform,
-		info.Compiler,
-		info.IsModified,
-		info.ModulePath)
+	b, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return "{}"
+	}
+	return string(b)
 }
 
 // IsRelease checks if this is a release build
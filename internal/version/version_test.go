@@ -0,0 +1,32 @@
+package version
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestGetJSONVersionFieldNames(t *testing.T) {
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(GetJSONVersion()), &fields); err != nil {
+		t.Fatalf("GetJSONVersion output is not JSON: %v", err)
+	}
+
+	want := []string{
+		"version", "git_commit", "git_branch", "git_tag", "build_date", "go_version",
+		"platform", "compiler", "is_modified", "module_path", "module_sum",
+	}
+	for _, name := range want {
+		if _, ok := fields[name]; !ok {
+			t.Errorf("field %q missing from %v", name, fields)
+		}
+	}
+	for name := range fields {
+		if !slices.Contains(want, name) {
+			t.Errorf("unexpected field %q", name)
+		}
+	}
+	if _, ok := fields["is_modified"].(bool); !ok {
+		t.Errorf("is_modified is %T, want a boolean", fields["is_modified"])
+	}
+}